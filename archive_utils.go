@@ -0,0 +1,227 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// 归档格式
+const (
+	ArchiveTar   = "tar"
+	ArchiveTarGz = "tar.gz"
+	ArchiveZip   = "zip"
+)
+
+// archiveFormat 根据文件名判断归档格式
+func archiveFormat(name string) (string, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGz, nil
+	case strings.HasSuffix(lower, ".tar"):
+		return ArchiveTar, nil
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip, nil
+	}
+	return "", fmt.Errorf("不支持的归档格式: %s（支持 .tar、.tar.gz、.tgz、.zip）", name)
+}
+
+// isArchiveEntryTooLarge 检查条目是否超过大小限制，超过的条目不读入内存而是直接复制
+func isArchiveEntryTooLarge(size int64) bool {
+	return !forceMode && size > int64(maxFileSize)
+}
+
+// stripArchiveEntry 删除归档条目中的注释，返回处理结果以及内容是否发生变化
+// 不支持、不安全或无法识别类型的条目原样返回
+func stripArchiveEntry(name string, content []byte) ([]byte, bool) {
+	if !isSupportedFile(name, forceMode) {
+		return content, false
+	}
+	if err := isFileSafe(name, content, forceMode); err != nil {
+		skippedFiles = append(skippedFiles, name)
+		printWarning("%s", err.Error())
+		return content, false
+	}
+	fileType := detectFileTypeFromContent(name, content)
+	if fileType == "unknown" {
+		skippedFiles = append(skippedFiles, name)
+		printWarning("无法识别文件类型: %s", name)
+		return content, false
+	}
+
+	processedFiles = append(processedFiles, name)
+	result := removeComments(string(content), fileType)
+	if result == string(content) {
+		fmt.Printf("%s "+ColorYellow+"|%s|"+ColorReset+" 无变化\n", name, strings.ToUpper(fileType))
+		return content, false
+	}
+	fmt.Printf("%s "+ColorGreen+"|%s|"+ColorReset+" "+ColorGreen+"✓"+ColorReset+"\n", name, strings.ToUpper(fileType))
+	return []byte(result), true
+}
+
+// processArchive 读取归档文件，删除其中源码条目的注释并写出新的归档
+// 其他条目按原样复制，条目的权限、修改时间和符号链接等头信息保持不变
+func processArchive(inPath, outPath string) error {
+	inFormat, err := archiveFormat(inPath)
+	if err != nil {
+		return err
+	}
+	outFormat, err := archiveFormat(outPath)
+	if err != nil {
+		return err
+	}
+	// tar 与 zip 的头信息无法一一对应，转换会丢失元数据
+	if (inFormat == ArchiveZip) != (outFormat == ArchiveZip) {
+		return fmt.Errorf("不支持在 tar 与 zip 之间转换: %s -> %s", inFormat, outFormat)
+	}
+
+	in, err := os.Open(inPath)
+	if err != nil {
+		return fmt.Errorf("打开归档失败: %v", err)
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("读取归档信息失败: %v", err)
+	}
+
+	// 先写入临时文件，输出与输入相同时也不会破坏正在读取的归档
+	out, err := createAtomicFile(outPath, inPath, info)
+	if err != nil {
+		return err
+	}
+	if inFormat == ArchiveZip {
+		err = stripZipArchive(in, info.Size(), out)
+	} else {
+		err = stripTarArchive(in, inFormat == ArchiveTarGz, out, outFormat == ArchiveTarGz)
+	}
+	if err != nil {
+		out.Abort()
+		return err
+	}
+	return out.Commit()
+}
+
+// stripTarArchive 逐个条目处理 tar 归档，gzip 压缩的输入和输出分别由 gzIn、gzOut 指定
+func stripTarArchive(r io.Reader, gzIn bool, w io.Writer, gzOut bool) error {
+	var gzHeader gzip.Header
+	if gzIn {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("读取 gzip 失败: %v", err)
+		}
+		defer zr.Close()
+		gzHeader = zr.Header
+		r = zr
+	}
+	var zw *gzip.Writer
+	if gzOut {
+		zw = gzip.NewWriter(w)
+		// 保留原 gzip 头中的文件名、时间等信息
+		zw.Header = gzHeader
+		w = zw
+	}
+
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取 tar 条目失败: %v", err)
+		}
+
+		// 目录、符号链接、硬链接等条目只有头信息
+		if !hdr.FileInfo().Mode().IsRegular() || isArchiveEntryTooLarge(hdr.Size) {
+			if err := tw.WriteHeader(hdr); err != nil {
+				return fmt.Errorf("写入 tar 条目 %s 失败: %v", hdr.Name, err)
+			}
+			if _, err := io.Copy(tw, tr); err != nil {
+				return fmt.Errorf("复制 tar 条目 %s 失败: %v", hdr.Name, err)
+			}
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("读取 tar 条目 %s 失败: %v", hdr.Name, err)
+		}
+		result, _ := stripArchiveEntry(hdr.Name, content)
+		hdr.Size = int64(len(result))
+		// PAX 头中的 size 记录以 Header.Size 为准
+		delete(hdr.PAXRecords, "size")
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("写入 tar 条目 %s 失败: %v", hdr.Name, err)
+		}
+		if _, err := tw.Write(result); err != nil {
+			return fmt.Errorf("写入 tar 条目 %s 失败: %v", hdr.Name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if zw != nil {
+		return zw.Close()
+	}
+	return nil
+}
+
+// stripZipArchive 逐个条目处理 zip 归档，未修改的条目直接复制压缩数据
+func stripZipArchive(r io.ReaderAt, size int64, w io.Writer) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("读取 zip 失败: %v", err)
+	}
+
+	zw := zip.NewWriter(w)
+	if err := zw.SetComment(zr.Comment); err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() || isArchiveEntryTooLarge(int64(f.UncompressedSize64)) {
+			if err := zw.Copy(f); err != nil {
+				return fmt.Errorf("复制 zip 条目 %s 失败: %v", f.Name, err)
+			}
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("读取 zip 条目 %s 失败: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("读取 zip 条目 %s 失败: %v", f.Name, err)
+		}
+
+		result, changed := stripArchiveEntry(f.Name, content)
+		if !changed {
+			if err := zw.Copy(f); err != nil {
+				return fmt.Errorf("复制 zip 条目 %s 失败: %v", f.Name, err)
+			}
+			continue
+		}
+
+		// 沿用原条目的头信息（名称、压缩方式、时间、权限、扩展字段），大小和校验和由写入时重新计算
+		header := f.FileHeader
+		header.CRC32 = 0
+		header.CompressedSize64 = 0
+		header.UncompressedSize64 = 0
+		fw, err := zw.CreateHeader(&header)
+		if err != nil {
+			return fmt.Errorf("写入 zip 条目 %s 失败: %v", f.Name, err)
+		}
+		if _, err := fw.Write(result); err != nil {
+			return fmt.Errorf("写入 zip 条目 %s 失败: %v", f.Name, err)
+		}
+	}
+	return zw.Close()
+}
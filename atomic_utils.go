@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// atomicFile 目标文件同目录下的临时文件，提交时继承原文件元数据并通过 rename 原子替换目标文件
+type atomicFile struct {
+	*os.File
+	target string
+	source string // 元数据（扩展属性）的来源文件，备份、恢复和镜像输出时与 target 不同
+	info   os.FileInfo
+}
+
+// createAtomicFile 在目标文件所在目录创建临时文件，source 和 info 为原文件的路径和信息（新文件可传 "" 和 nil）
+func createAtomicFile(target, source string, info os.FileInfo) (*atomicFile, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".tmp*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	return &atomicFile{File: tmp, target: target, source: source, info: info}, nil
+}
+
+// Commit 刷盘、复制元数据并替换目标文件
+func (f *atomicFile) Commit() error {
+	tmpPath := f.Name()
+	if err := f.Sync(); err != nil {
+		f.Abort()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	
+	if f.info != nil {
+		if err := applyFileMetadata(tmpPath, f.source, f.info); err != nil {
+			os.Remove(tmpPath)
+			return err
+		}
+	}
+	if err := os.Rename(tmpPath, f.target); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// Abort 放弃写入并删除临时文件
+func (f *atomicFile) Abort() {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// writeFileAtomic 原子地写入文件内容并继承 source 的元数据，崩溃时不会留下被截断的源文件
+func writeFileAtomic(target, source string, data []byte, info os.FileInfo) error {
+	f, err := createAtomicFile(target, source, info)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Commit()
+}
+
+// applyFileMetadata 将原文件的权限、属主、扩展属性以及（可选）修改时间应用到 dst
+// 属主和扩展属性在没有权限时静默跳过
+func applyFileMetadata(dst, src string, info os.FileInfo) error {
+	// 先修改属主，chown 可能清除 setuid/setgid 位，因此放在 chmod 之前
+	copyOwnership(dst, info)
+	
+	if err := os.Chmod(dst, info.Mode()&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky)); err != nil {
+		return fmt.Errorf("设置文件权限失败: %v", err)
+	}
+	
+	if src != "" {
+		copyXattrs(dst, src)
+	}
+	
+	if preserveMtime {
+		if err := os.Chtimes(dst, fileAccessTime(info), info.ModTime()); err != nil {
+			return fmt.Errorf("设置修改时间失败: %v", err)
+		}
+	}
+	
+	return nil
+}
+
+// copyFileWithMetadata 复制文件内容并继承元数据，用于创建备份
+func copyFileWithMetadata(dst, src string, info os.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	
+	f, err := createAtomicFile(dst, src, info)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, in); err != nil {
+		f.Abort()
+		return err
+	}
+	return f.Commit()
+}
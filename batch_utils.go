@@ -0,0 +1,84 @@
+package main
+
+import "strings"
+
+// isBatchFileType 检查文件类型是否为 Windows 批处理脚本
+func isBatchFileType(fileType string) bool {
+	return fileType == "bat" || fileType == "cmd"
+}
+
+// isBatchRem 检查 line[pos:] 是否为 REM 命令（不区分大小写，REM 之后必须是空白或行尾，REMARK 不是注释）
+func isBatchRem(line string, pos int) bool {
+	if pos+3 > len(line) || !strings.EqualFold(line[pos:pos+3], "rem") {
+		return false
+	}
+	return pos+3 == len(line) || line[pos+3] == ' ' || line[pos+3] == '\t'
+}
+
+// batchCommentStart 返回批处理脚本一行中注释开始的位置，没有注释时返回 -1
+// REM 只在命令开头时是注释（包括 @REM 和代码块开头的 ( REM）；:: 只在行首是注释；
+// & REM、&& REM、|| REM 从分隔符开始删除。引号中的内容和 ^ 转义的字符不参与判断，% 变量不是注释
+func batchCommentStart(line string) int {
+	inQuote := false
+	cmdStart := true // 当前位置是否为命令开头
+	cmdBegin := -1   // 当前命令开头的 @ 位置
+	separator := -1  // 当前命令之前的 & 或 | 的位置
+	firstWord := ""  // 当前命令的第一个单词（小写），if 和 else 之后的 ( 开始代码块
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if inQuote {
+			if c == '"' {
+				inQuote = false
+			}
+			continue
+		}
+
+		switch {
+		case c == ' ' || c == '\t':
+		case c == '&' || c == '|':
+			separator = i
+			if i+1 < len(line) && line[i+1] == c {
+				i++
+			}
+			cmdStart, cmdBegin, firstWord = true, -1, ""
+		case c == '(':
+			// 代码块中的第一个命令：( REM、if ... ( REM、else ( REM
+			if cmdStart || firstWord == "if" || firstWord == "else" {
+				cmdStart, cmdBegin, firstWord = true, -1, ""
+			}
+		case c == ')' && cmdStart:
+			// ) else ( 中的 ) 之后仍是命令开头
+		case c == '@' && cmdStart:
+			if cmdBegin == -1 {
+				cmdBegin = i
+			}
+		case cmdStart && isBatchRem(line, i):
+			switch {
+			case separator != -1:
+				return separator
+			case cmdBegin != -1:
+				return cmdBegin
+			}
+			return i
+		case cmdStart && separator == -1 && strings.HasPrefix(line[i:], "::"):
+			return i
+		case c == '"':
+			inQuote, cmdStart = true, false
+		case c == '^':
+			i++
+			cmdStart = false
+		default:
+			start := i
+			for i+1 < len(line) && strings.IndexByte(" \t&|()<>\"^", line[i+1]) == -1 {
+				i++
+			}
+			word := strings.ToLower(line[start : i+1])
+			if cmdStart {
+				firstWord = word
+			}
+			cmdStart = false
+		}
+	}
+	return -1
+}
@@ -0,0 +1,111 @@
+package main
+
+import "strings"
+
+// COBOL 固定格式的列区域（从 0 开始的下标）：第 1-6 列为序号区，第 7 列为指示区，第 8-72 列为程序区，第 73 列之后为标识区
+const (
+	cobolIndicatorColumn = 6
+	cobolProgramEnd      = 72
+)
+
+// isCobolFileType 检查文件类型是否为 COBOL
+func isCobolFileType(fileType string) bool {
+	return fileType == "cobol" || fileType == "cob" || fileType == "cbl"
+}
+
+// cobolSourceFormat 检查一行是否为切换源码格式的指令，返回是否为自由格式和是否为指令
+// 支持 >>SOURCE [FORMAT] [IS] FREE|FIXED 和 Micro Focus 的 $SET SOURCEFORMAT"FREE"
+func cobolSourceFormat(line string) (bool, bool) {
+	upper := strings.ToUpper(line)
+	if !strings.Contains(upper, ">>SOURCE") && !strings.Contains(upper, "SOURCEFORMAT") {
+		return false, false
+	}
+	switch {
+	case strings.Contains(upper, "FREE"):
+		return true, true
+	case strings.Contains(upper, "FIXED"):
+		return false, true
+	}
+	return false, false
+}
+
+// cobolCommentStart 返回 COBOL 一行中 *> 注释开始的位置（没有时为 -1）和行尾仍未结束的字符串引号
+// 从 start 开始扫描到 end，quote 非 0 时从字符串中开始；字符串中连续两个引号表示引号本身
+func cobolCommentStart(line string, start, end int, quote byte) (int, byte) {
+	for i := start; i < end; i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '*' && i+1 < end && line[i+1] == '>':
+			return i, 0
+		}
+	}
+	return -1, quote
+}
+
+// processCobolLine 处理 COBOL 的一行
+// 固定格式中第 7 列为 * 或 / 的行是注释行，第 7 列为 - 的续行从程序区的引号继续未结束的字符串；
+// *> 注释在字符串之外生效，删除时用空格填充以保持标识区（第 73 列之后）的列位置。自由格式中只有 *> 注释
+func (s *commentStripper) processCobolLine(line string) (string, bool) {
+	if free, ok := cobolSourceFormat(line); ok {
+		s.cobolFree = free
+		s.cobolQuote = 0
+		return line, true
+	}
+
+	if s.cobolFree {
+		pos, _ := cobolCommentStart(line, 0, len(line), 0)
+		if pos == -1 {
+			return line, true
+		}
+		before := strings.TrimRight(line[:pos], " \t")
+		if strings.TrimSpace(before) == "" {
+			return "", false
+		}
+		return before, true
+	}
+
+	if len(line) <= cobolIndicatorColumn {
+		// 只有序号区
+		return line, true
+	}
+	quote := byte(0)
+	start := cobolIndicatorColumn + 1
+	switch line[cobolIndicatorColumn] {
+	case '*', '/':
+		return "", false
+	case '-':
+		if s.cobolQuote != 0 {
+			// 续行的字符串从程序区中第一个引号之后继续
+			if i := strings.IndexByte(line[start:], s.cobolQuote); i != -1 {
+				start += i + 1
+				quote = s.cobolQuote
+			}
+		}
+	}
+
+	end := len(line)
+	if end > cobolProgramEnd {
+		end = cobolProgramEnd
+	}
+	pos, open := cobolCommentStart(line, start, end, quote)
+	s.cobolQuote = open
+	if pos == -1 {
+		return line, true
+	}
+	if len(line) > cobolProgramEnd {
+		// 保留标识区
+		return line[:pos] + strings.Repeat(" ", cobolProgramEnd-pos) + line[cobolProgramEnd:], true
+	}
+	before := strings.TrimRight(line[:pos], " \t")
+	if len(before) <= cobolIndicatorColumn+1 && line[cobolIndicatorColumn] != '-' {
+		// 程序区只有注释，整行删除
+		return "", false
+	}
+	return before, true
+}
@@ -7,6 +7,8 @@ type CommentRule struct {
 	StartPattern string
 	EndPattern   string
 	IsLineComment bool
+	Nested       bool // 块注释可以嵌套（如 Rust 的 /* /* */ */）
+	LongBracket  bool // Lua 长括号：StartPattern 以 [ 结尾，之后是若干 = 和 [，结束标记是 ]、相同个数的 = 和 ]
 	ProtectFunc  func(line string, pos int) bool
 }
 
@@ -32,47 +34,20 @@ func shouldProtectInContext(line string, pos int, fileType string, commentStart
 // checkProtectionRules 检查保护规则
 func checkProtectionRules(ctx ProtectionContext) bool {
 	switch ctx.FileType {
-	case "c", "cpp", "cc", "cxx", "h", "hpp", "java", "javascript", "js", "typescript", "ts", "go", "rust", "rs", "swift", "kotlin", "scala", "dart", "cs":
+	case "c", "cpp", "cc", "cxx", "h", "hpp", "java", "javascript", "js", "mjs", "cjs", "jsx", "typescript", "ts", "mts", "cts", "tsx", "go", "rust", "rs", "swift", "kotlin", "scala", "dart", "cs":
 		// 首先检查是否在普通字符串内（单引号或双引号）
-		if isInStringWithType(ctx.Line, ctx.Pos, StringTypeQuote) {
+		if isInStringForLanguage(ctx.Line, ctx.Pos, StringTypeQuote, ctx.FileType) {
 			return true
 		}
 		
 		// 保护反引号代码块中的注释符号
-		if isInStringWithType(ctx.Line, ctx.Pos, StringTypeBacktick) {
+		if isInStringForLanguage(ctx.Line, ctx.Pos, StringTypeBacktick, ctx.FileType) {
 			return true
 		}
 		
-		// JavaScript正则表达式保护
-		if (ctx.FileType == "javascript" || ctx.FileType == "js") && (ctx.CommentStart == "//" || ctx.CommentStart == "/*") {
-			beforeComment := ctx.Line[:ctx.Pos]
-			// 检查是否在正则表达式字面量内
-			if strings.Contains(beforeComment, "= /") || strings.Contains(beforeComment, "(/") || 
-			   strings.Contains(beforeComment, " /") || strings.Contains(beforeComment, "\t/") {
-				// 更精确的正则表达式检测
-				regexStart := -1
-				for i := len(beforeComment) - 1; i >= 0; i-- {
-					if beforeComment[i] == '/' && (i == 0 || 
-						beforeComment[i-1] == '=' || beforeComment[i-1] == '(' || 
-						beforeComment[i-1] == ' ' || beforeComment[i-1] == '\t') {
-						regexStart = i
-						break
-					}
-				}
-				if regexStart != -1 {
-					// 检查从正则开始到当前位置是否没有结束的斜杠
-					afterRegexStart := beforeComment[regexStart+1:]
-					unescapedSlashCount := 0
-					for i, char := range afterRegexStart {
-						if char == '/' && !isEscaped(afterRegexStart, i) {
-							unescapedSlashCount++
-						}
-					}
-					if unescapedSlashCount == 0 {
-						return true // 在正则表达式内
-					}
-				}
-			}
+		// JavaScript/TypeScript正则表达式保护（根据前一个记号区分正则表达式和除号）
+		if isJSFileType(ctx.FileType) && (ctx.CommentStart == "//" || ctx.CommentStart == "/*") && isInJSRegex(ctx.Line, ctx.Pos) {
+			return true
 		}
 		
 		// 特殊处理：检查是否在字符串拼接中的反引号代码块内
@@ -167,8 +142,8 @@ func checkProtectionRules(ctx ProtectionContext) bool {
 	case "css", "scss", "sass", "less":
 		// CSS中保护URL和content属性中的注释符号
 		if ctx.CommentStart == "/*" || ctx.CommentStart == "//" {
-			// 检查是否在url()函数中
-			if strings.Contains(ctx.Line[:ctx.Pos], "url(") && !strings.Contains(ctx.Line[:ctx.Pos], ")") {
+			// 检查是否在url()函数中（如 url(//cdn.example.com/a.png)）
+			if before := ctx.Line[:ctx.Pos]; strings.LastIndex(before, "url(") > strings.LastIndex(before, ")") {
 				return true
 			}
 			// 检查是否在content属性中
@@ -214,8 +189,13 @@ func checkProtectionRules(ctx ProtectionContext) bool {
 		}
 	case "python", "py":
 		return checkPythonProtection(ctx)
-	case "shell", "bash", "zsh", "sh":
-		return checkShellProtection(ctx)
+	case "sql", "mysql", "psql", "plsql":
+		return checkSQLProtection(ctx)
+	case "matlab", "m":
+		// %{ 只有单独占一行时才开始块注释，否则是普通的 % 行注释
+		if ctx.CommentStart == "%{" && strings.TrimSpace(ctx.Line) != "%{" {
+			return true
+		}
 	}
 	// Rust特殊保护
 	if ctx.FileType == "rust" || ctx.FileType == "rs" {
@@ -230,7 +210,7 @@ func checkProtectionRules(ctx ProtectionContext) bool {
 	}
 	
 	// 通用字符串保护
-	return isInAnyString(ctx.Line, ctx.Pos)
+	return isInStringForLanguage(ctx.Line, ctx.Pos, StringTypeAll, ctx.FileType)
 }
 
 // checkPythonProtection 检查Python的保护规则
@@ -299,59 +279,6 @@ func checkPythonProtection(ctx ProtectionContext) bool {
 	return false
 }
 
-// checkShellProtection 检查Shell脚本的保护规则
-func checkShellProtection(ctx ProtectionContext) bool {
-	if ctx.CommentStart == "#" {
-		// 首先检查是否在普通字符串内
-		if isInStringWithType(ctx.Line, ctx.Pos, StringTypeQuote) {
-			return true
-		}
-		
-		// 保护shebang
-		if ctx.Pos == 0 && strings.HasPrefix(ctx.Line, "#!") {
-			return true
-		}
-		// 保护变量替换中的#，如 ${GITHUB_REF#refs/tags/}
-		beforeComment := ctx.Line[:ctx.Pos]
-		if strings.Contains(beforeComment, "${") {
-			// 检查是否在变量替换的#操作符位置
-			if strings.Count(beforeComment, "{") > strings.Count(beforeComment, "}") {
-				return true
-			}
-		}
-		// 保护条件语句中的#
-		if strings.Contains(beforeComment, "[ ") && !strings.Contains(beforeComment, " ]") {
-			return true
-		}
-		// 保护字符串中的URL锚点（只有当#确实在字符串内部时才保护）
-		if isInAnyString(ctx.Line, ctx.Pos) && strings.Contains(beforeComment, "http") {
-			return true
-		}
-		// 保护颜色代码（更精确的检查）
-		if strings.Contains(beforeComment, "#") && len(beforeComment) >= 6 {
-			// 检查是否是颜色代码格式
-			lastHash := strings.LastIndex(beforeComment, "#")
-			if lastHash >= 0 && lastHash < len(beforeComment)-1 {
-				afterHash := beforeComment[lastHash+1:]
-				if len(afterHash) == 6 || len(afterHash) == 3 {
-					// 检查是否全为十六进制字符
-					isHex := true
-					for _, c := range afterHash {
-						if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')) {
-							isHex = false
-							break
-						}
-					}
-					if isHex {
-						return true
-					}
-				}
-			}
-		}
-	}
-	return false
-}
-
 // getCommentRulesForLanguage 获取指定语言的注释规则
 func getCommentRulesForLanguage(fileType string) []CommentRule {
 	// C风格语言 (// 和 /* */)
@@ -360,6 +287,12 @@ func getCommentRulesForLanguage(fileType string) []CommentRule {
 		{StartPattern: "/*", EndPattern: "*/", IsLineComment: false},
 	}
 	
+	// 块注释可以嵌套的 C 风格语言
+	nestedCStyleRules := []CommentRule{
+		{StartPattern: "//", EndPattern: "", IsLineComment: true},
+		{StartPattern: "/*", EndPattern: "*/", IsLineComment: false, Nested: true},
+	}
+	
 	// 井号注释语言 (#)
 	hashStyleRules := []CommentRule{
 		{StartPattern: "#", EndPattern: "", IsLineComment: true},
@@ -372,14 +305,21 @@ func getCommentRulesForLanguage(fileType string) []CommentRule {
 	_ = dashStyleRules // 避免未使用变量错误
 	
 	switch fileType {
-	case "javascript", "js", "typescript", "ts", "go":
+	case "javascript", "js", "mjs", "cjs", "jsx", "typescript", "ts", "mts", "cts", "tsx", "go":
 		return cStyleRules
 	case "c", "cpp", "cc", "cxx", "h", "hpp":
 		return cStyleRules
-	case "java", "scala", "kotlin", "kt", "groovy":
-		return cStyleRules
-	case "rust", "rs", "swift", "dart", "cs":
+	case "java", "groovy", "cs":
 		return cStyleRules
+	case "rust", "rs", "swift", "dart", "scala", "kotlin", "kt", "odin":
+		return nestedCStyleRules
+	case "d":
+		// D 的 /+ +/ 可以嵌套，/* */ 不能
+		return []CommentRule{
+			{StartPattern: "//", EndPattern: "", IsLineComment: true},
+			{StartPattern: "/+", EndPattern: "+/", IsLineComment: false, Nested: true},
+			{StartPattern: "/*", EndPattern: "*/", IsLineComment: false},
+		}
 	case "php":
 		// PHP支持多种注释风格
 		return []CommentRule{
@@ -387,35 +327,70 @@ func getCommentRulesForLanguage(fileType string) []CommentRule {
 			{StartPattern: "/*", EndPattern: "*/", IsLineComment: false},
 			{StartPattern: "#", EndPattern: "", IsLineComment: true},
 		}
-	case "python", "py", "ruby", "rb", "shell", "bash", "zsh", "sh", "fish":
+	case "python", "py", "ruby", "rb", "shell", "bash", "zsh", "sh", "ksh", "fish":
 		return hashStyleRules
 	case "perl", "pl", "pm", "tcl":
 		return hashStyleRules
-	case "r", "R":
+	case "bat", "cmd":
+		// REM 和 :: 只在命令开头时是注释，由 batchCommentStart 识别
+		return []CommentRule{
+			{StartPattern: "REM", EndPattern: "", IsLineComment: true},
+		}
+	case "ps1":
+		// <# #> 块注释在 # 行注释之后检查，行注释的扫描会跳过同一行内完整的 <# #>
+		return []CommentRule{
+			{StartPattern: "#", EndPattern: "", IsLineComment: true},
+			{StartPattern: "<#", EndPattern: "#>", IsLineComment: false},
+		}
+	case "r", "R", "julia", "jl":
 		return hashStyleRules
 	case "yaml", "yml", "toml", "ini", "cfg", "conf":
 		return hashStyleRules
-	case "sql", "plsql", "psql":
+	case "sql", "plsql":
+		return []CommentRule{
+			{StartPattern: "--", EndPattern: "", IsLineComment: true},
+			{StartPattern: "/*", EndPattern: "*/", IsLineComment: false},
+		}
+	case "mysql":
 		return []CommentRule{
 			{StartPattern: "--", EndPattern: "", IsLineComment: true},
+			{StartPattern: "#", EndPattern: "", IsLineComment: true},
 			{StartPattern: "/*", EndPattern: "*/", IsLineComment: false},
 		}
+	case "psql":
+		// PostgreSQL 的块注释可以嵌套
+		return []CommentRule{
+			{StartPattern: "--", EndPattern: "", IsLineComment: true},
+			{StartPattern: "/*", EndPattern: "*/", IsLineComment: false, Nested: true},
+		}
 	case "lua":
+		// --[[ ]]、--[==[ ]==] 是长注释，其他 -- 是行注释
 		return []CommentRule{
 			{StartPattern: "--", EndPattern: "", IsLineComment: true},
-			{StartPattern: "--[[", EndPattern: "]]", IsLineComment: false},
+			{StartPattern: "--[", EndPattern: "]]", IsLineComment: false, LongBracket: true},
 		}
 	case "haskell", "hs", "elm":
 		return []CommentRule{
 			{StartPattern: "--", EndPattern: "", IsLineComment: true},
-			{StartPattern: "{-", EndPattern: "-}", IsLineComment: false},
+			{StartPattern: "{-", EndPattern: "-}", IsLineComment: false, Nested: true},
 		}
 	case "ml", "ocaml":
 		return []CommentRule{
-			{StartPattern: "(*", EndPattern: "*)", IsLineComment: false},
+			{StartPattern: "(*", EndPattern: "*)", IsLineComment: false, Nested: true},
 		}
-	case "css", "scss", "sass", "less":
+	case "fs", "fsx", "fsharp":
 		return []CommentRule{
+			{StartPattern: "//", EndPattern: "", IsLineComment: true},
+			{StartPattern: "(*", EndPattern: "*)", IsLineComment: false, Nested: true},
+		}
+	case "css":
+		return []CommentRule{
+			{StartPattern: "/*", EndPattern: "*/", IsLineComment: false},
+		}
+	case "scss", "sass", "less":
+		// 预处理器另有 // 行注释
+		return []CommentRule{
+			{StartPattern: "//", EndPattern: "", IsLineComment: true},
 			{StartPattern: "/*", EndPattern: "*/", IsLineComment: false},
 		}
 	case "html", "htm", "xml", "svg":
@@ -440,8 +415,10 @@ func getCommentRulesForLanguage(fileType string) []CommentRule {
 	case "fortran", "f", "f90", "f95", "for":
 		return []CommentRule{
 			{StartPattern: "!", EndPattern: "", IsLineComment: true},
-			{StartPattern: "C", EndPattern: "", IsLineComment: true},
-			{StartPattern: "c", EndPattern: "", IsLineComment: true},
+		}
+	case "cobol", "cob", "cbl":
+		return []CommentRule{
+			{StartPattern: "*>", EndPattern: "", IsLineComment: true},
 		}
 	case "lisp", "lsp", "scm", "clj", "cljs":
 		return []CommentRule{
@@ -463,344 +440,630 @@ func getCommentRulesForLanguage(fileType string) []CommentRule {
 	}
 }
 
-// removeCommentsByRules 根据注释规则删除注释
-func removeCommentsByRules(content string, fileType string, rules []CommentRule) string {
-	lines := strings.Split(content, "\n")
-	var result []string
-	var inBlockComment bool
-	var inMultiLineString bool
-	var inBacktickString bool
-	inYAMLMultiLineBlock := false
-	yamlBlockIndent := 0
-	var blockEndPattern string
+// commentStripper 逐行删除注释的有状态处理器，跨行状态（块注释、多行字符串等）保存在结构体中
+type commentStripper struct {
+	fileType string
+	rules    []CommentRule
 
-	for _, line := range lines {
-		originalLine := line
-		processedLine := line
-		
-		// 如果是空行，直接保留
-		if strings.TrimSpace(line) == "" {
-			result = append(result, line)
-			continue
+	inBlockComment       bool
+	blockDepth           int // 可嵌套块注释的当前深度
+	inMultiLineString    bool
+	inBacktickString     bool
+	inYAMLMultiLineBlock bool
+	yamlBlockIndent      int
+	blockRule            CommentRule
+
+	// 原始字符串和多行字符串
+	stringRules []StringRule
+	inRawString bool
+	rawRule     StringRule
+	rawEnd      string
+	heredocs    []string // 等待结束的 heredoc 标记，按出现顺序排列
+
+	// 行首标记的文档块（Ruby =begin/=end、Perl POD）和数据段（__END__ 之后）
+	inDocBlock    bool
+	docEnd        string // 文档块的结束指令
+	keepDocBlock  bool   // 原样保留当前文档块
+	inDataSection bool
+
+	fortranQuote byte // Fortran 延续到下一行的字符串引号
+	cobolFree    bool // COBOL 当前为自由格式（>>SOURCE FORMAT FREE 之后）
+	cobolQuote   byte // COBOL 固定格式中延续到续行的字符串引号
+
+	shellLine shellState // shell 脚本当前行开始时的引号和命令替换状态
+	shellOpen shellState // shell 脚本延续到下一行的引号和命令替换状态
+}
+
+// newCommentStripper 创建指定语言的逐行注释处理器
+func newCommentStripper(fileType string, rules []CommentRule) *commentStripper {
+	return &commentStripper{fileType: fileType, rules: rules, stringRules: getStringRulesForLanguage(fileType)}
+}
+
+// wordCommentStart 对按记号规则识别行注释的语言（shell、PowerShell、批处理）返回行注释的位置（没有时为 -1）和 true，
+// 其他语言返回 false
+func (s *commentStripper) wordCommentStart(line string) (int, bool) {
+	switch {
+	case isShellFileType(s.fileType):
+		pos, _ := shellCommentStart(line, s.fileType, s.shellLine)
+		return pos, true
+	case s.fileType == "ps1":
+		return powerShellCommentStart(line), true
+	case isBatchFileType(s.fileType):
+		return batchCommentStart(line), true
+	}
+	return -1, false
+}
+
+// scanStrings 扫描一行中的原始字符串和多行字符串，返回受保护的区间
+// 字符串到行尾仍未结束时进入跨行状态；heredoc 从下一行开始生效；遇到注释后停止扫描
+func (s *commentStripper) scanStrings(line string) [][2]int {
+	if len(s.stringRules) == 0 {
+		return nil
+	}
+	wordRules := false
+	if start, ok := s.wordCommentStart(line); ok {
+		// 行注释按记号规则确定，注释之后不再扫描
+		if start != -1 {
+			line = line[:start]
 		}
-		
-		// YAML多行字符串块检测
-		if fileType == "yaml" || fileType == "yml" {
-			trimmedLine := strings.TrimSpace(line)
-			currentIndent := len(line) - len(strings.TrimLeft(line, " \t"))
-			
-			// 检测多行字符串块开始 (|, >, |-, >-)
-			if strings.Contains(line, ": |") || strings.Contains(line, ": >") || 
-			   strings.Contains(line, ": |-") || strings.Contains(line, ": >-") {
-				inYAMLMultiLineBlock = true
-				yamlBlockIndent = currentIndent
-			} else if inYAMLMultiLineBlock {
-				// 检查是否退出多行字符串块
-				if trimmedLine != "" && currentIndent <= yamlBlockIndent {
-					inYAMLMultiLineBlock = false
+		wordRules = true
+	}
+	var ranges [][2]int
+	for i := 0; i < len(line); i++ {
+		// 同一位置有多个注释标记匹配时使用最长的标记
+		matched := false
+		var comment CommentRule
+		for _, rule := range s.rules {
+			if (wordRules && rule.IsLineComment) || !strings.HasPrefix(line[i:], rule.StartPattern) || isInStringForLanguage(maskRanges(line, ranges), i, StringTypeAll, s.fileType) ||
+				shouldProtectInContext(line, i, s.fileType, rule.StartPattern) {
+				continue
+			}
+			if rule.LongBracket {
+				level, ok := longBracketLevel(line, i+len(rule.StartPattern)-1)
+				if !ok {
+					continue
 				}
+				rule.StartPattern += strings.Repeat("=", level) + "["
+				rule.EndPattern = "]" + strings.Repeat("=", level) + "]"
 			}
-			
-			// 如果在YAML多行字符串块中，保护所有内容
-			if inYAMLMultiLineBlock {
-				result = append(result, originalLine)
+			if !matched || len(rule.StartPattern) > len(comment.StartPattern) {
+				matched, comment = true, rule
+			}
+		}
+		if matched {
+			if comment.IsLineComment {
+				return ranges
+			}
+			end, _ := findBlockCommentEnd(line[i+len(comment.StartPattern):], comment, 1)
+			if end == -1 {
+				return ranges
+			}
+			i += len(comment.StartPattern) + end - 1
+			continue
+		}
+
+		for _, rule := range s.stringRules {
+			bodyStart, end, ok := rule.matchOpen(line, i)
+			if !ok || isInStringForLanguage(maskRanges(line, ranges), i, StringTypeAll, s.fileType) {
 				continue
 			}
+			if rule.Kind == StringHeredoc {
+				s.heredocs = append(s.heredocs, end)
+				i = bodyStart - 1
+				break
+			}
+			stop := rule.findEnd(line, bodyStart, end)
+			if stop == -1 {
+				ranges = append(ranges, [2]int{i, len(line)})
+				s.inRawString = true
+				s.rawRule = rule
+				s.rawEnd = end
+				return ranges
+			}
+			ranges = append(ranges, [2]int{i, stop})
+			i = stop - 1
+			break
 		}
+	}
+	return ranges
+}
+
+// processLine 处理一行内容，返回处理后的行以及是否保留该行
+func (s *commentStripper) processLine(line string) (string, bool) {
+	originalLine := line
+	processedLine := line
+	
+	// 数据段是数据而不是代码，原样保留到文件末尾
+	if s.inDataSection {
+		return line, true
+	}
+	
+	// 文档块中的内容（包括空行）整体删除或保留，直到行首的结束指令
+	if s.inDocBlock {
+		if hasLineDirective(line, s.docEnd) {
+			s.inDocBlock = false
+		}
+		return line, s.keepDocBlock
+	}
+	
+	// 如果是空行，直接保留
+	if strings.TrimSpace(line) == "" {
+		return line, true
+	}
+	
+	// 跨行的原始字符串中的内容原样保留，结束后的部分继续处理
+	if s.inRawString {
+		end := s.rawRule.findEnd(line, 0, s.rawEnd)
+		if end == -1 {
+			return line, true
+		}
+		s.inRawString = false
+		rest, keep := s.processLine(line[end:])
+		if !keep {
+			rest = ""
+		}
+		return line[:end] + rest, true
+	}
+	
+	// heredoc 内容原样保留，直到单独一行的结束标记
+	if len(s.heredocs) > 0 {
+		if isHeredocTerminator(line, s.heredocs[0]) {
+			s.heredocs = s.heredocs[1:]
+		}
+		return line, true
+	}
+	
+	// Ruby/Perl 的数据段和文档块只在块注释之外的行首出现
+	if !s.inBlockComment {
+		if isDataSectionMarker(s.fileType, line) {
+			s.inDataSection = true
+			return line, true
+		}
+		if end, keep, ok := docBlockStart(s.fileType, line); ok {
+			s.inDocBlock, s.docEnd, s.keepDocBlock = true, end, keep
+			if hasLineDirective(line, end) {
+				// 单行的 =cut
+				s.inDocBlock = false
+			}
+			return line, keep
+		}
+	}
+	
+	// Fortran 的注释由列位置和续行决定
+	if isFortranFileType(s.fileType) {
+		return s.processFortranLine(line)
+	}
+	
+	// COBOL 固定格式按列判断注释行，自由格式只有 *> 注释
+	if isCobolFileType(s.fileType) {
+		return s.processCobolLine(line)
+	}
+	
+	// YAML多行字符串块检测
+	if s.fileType == "yaml" || s.fileType == "yml" {
+		trimmedLine := strings.TrimSpace(line)
+		currentIndent := len(line) - len(strings.TrimLeft(line, " \t"))
 		
-		// 检查多行字符串状态 - 在处理注释之前更新状态
-		oldMultiLineState := inMultiLineString
-		oldBacktickState := inBacktickString
-		
-		// 跟踪反引号字符串状态（用于Go/JS/TS模板字符串）
-		if fileType == "go" || fileType == "js" || fileType == "ts" || fileType == "jsx" || fileType == "tsx" || fileType == "javascript" {
-			backtickCount := 0
-			for i := 0; i < len(line); i++ {
-				if line[i] == '`' && !isEscaped(line, i) {
-					backtickCount++
-				}
+		// 检测多行字符串块开始 (|, >, |-, >-)
+		if strings.Contains(line, ": |") || strings.Contains(line, ": >") || 
+		   strings.Contains(line, ": |-") || strings.Contains(line, ": >-") {
+			s.inYAMLMultiLineBlock = true
+			s.yamlBlockIndent = currentIndent
+		} else if s.inYAMLMultiLineBlock {
+			// 检查是否退出多行字符串块
+			if trimmedLine != "" && currentIndent <= s.yamlBlockIndent {
+				s.inYAMLMultiLineBlock = false
 			}
-			if backtickCount%2 == 1 {
-				inBacktickString = !inBacktickString
+		}
+		
+		// 如果在YAML多行字符串块中，保护所有内容
+		if s.inYAMLMultiLineBlock {
+			return originalLine, true
+		}
+	}
+	
+	// 检查多行字符串状态 - 在处理注释之前更新状态
+	// 行首的跨行状态，行内块注释之后的内容重新处理时恢复
+	lineState := *s
+	oldMultiLineState := s.inMultiLineString
+	oldBacktickState := s.inBacktickString
+	
+	// shell 的引号和 $(...) 可以跨行，多行字符串中以 # 开头的行不是注释
+	if isShellFileType(s.fileType) {
+		s.shellLine = s.shellOpen
+		_, s.shellOpen = shellCommentStart(line, s.fileType, s.shellLine)
+	}
+	
+	// 跟踪反引号字符串状态（用于Go/JS/TS模板字符串）
+	if s.fileType == "go" || s.fileType == "js" || s.fileType == "ts" || s.fileType == "jsx" || s.fileType == "tsx" || s.fileType == "javascript" {
+		backtickCount := 0
+		for i := 0; i < len(line); i++ {
+			if line[i] == '`' && !isEscaped(line, i) {
+				backtickCount++
 			}
 		}
+		if backtickCount%2 == 1 {
+			s.inBacktickString = !s.inBacktickString
+		}
+	}
+	
+	// Python docstring 处理
+	if s.fileType == "python" || s.fileType == "py" {
+		tempInMultiLine := s.inMultiLineString
+		singleLineDocstring := false
 		
-		// Python docstring 处理
-		if fileType == "python" || fileType == "py" {
-			tempInMultiLine := inMultiLineString
-			singleLineDocstring := false
-			
-			// 检查是否有三引号
-			if strings.Contains(line, `"""`) || strings.Contains(line, "'''") {
-				// 检查单行docstring
-				if strings.Count(line, `"""`) >= 2 || strings.Count(line, "'''") >= 2 {
-					// 可能是单行docstring
-					startPos := -1
-					endPos := -1
-					quote := ""
-					
-					if pos := strings.Index(line, `"""`); pos != -1 {
-						startPos = pos
-						quote = `"""`
-					} else if pos := strings.Index(line, "'''"); pos != -1 {
-						startPos = pos
-						quote = "'''"
+		// 检查是否有三引号
+		if strings.Contains(line, `"""`) || strings.Contains(line, "'''") {
+			// 检查单行docstring
+			if strings.Count(line, `"""`) >= 2 || strings.Count(line, "'''") >= 2 {
+				// 可能是单行docstring
+				startPos := -1
+				endPos := -1
+				quote := ""
+				
+				if pos := strings.Index(line, `"""`); pos != -1 {
+					startPos = pos
+					quote = `"""`
+				} else if pos := strings.Index(line, "'''"); pos != -1 {
+					startPos = pos
+					quote = "'''"
+				}
+				
+				if startPos != -1 {
+					// 查找结束位置
+					endPos = strings.Index(line[startPos+3:], quote)
+					if endPos != -1 {
+						endPos += startPos + 3 + 3 // 加上开始位置和三引号长度
 					}
 					
-					if startPos != -1 {
-						// 查找结束位置
-						endPos = strings.Index(line[startPos+3:], quote)
-						if endPos != -1 {
-							endPos += startPos + 3 + 3 // 加上开始位置和三引号长度
-						}
-						
-						if endPos < len(line) {
-							beforeEnd := line[:endPos]
-							afterEnd := line[endPos:]
-							// 删除docstring后的注释
-							if pos := strings.Index(afterEnd, "#"); pos != -1 {
-								afterEnd = strings.TrimRight(afterEnd[:pos], " \t")
-							}
-							processedLine = beforeEnd + afterEnd
-							singleLineDocstring = true
-						} else {
-							// 单行docstring占据整行，不影响多行状态
-							singleLineDocstring = true
+					if endPos < len(line) {
+						beforeEnd := line[:endPos]
+						afterEnd := line[endPos:]
+						// 删除docstring后的注释
+						if pos := strings.Index(afterEnd, "#"); pos != -1 {
+							afterEnd = strings.TrimRight(afterEnd[:pos], " \t")
 						}
+						processedLine = beforeEnd + afterEnd
+						singleLineDocstring = true
+					} else {
+						// 单行docstring占据整行，不影响多行状态
+						singleLineDocstring = true
 					}
 				}
-				
-				if !singleLineDocstring {
-					// 计算不在字符串内的三引号数量
-					count := 0
-					for i := 0; i <= len(line)-3; i++ {
-						if line[i:i+3] == "'''" && !isInQuoteString(line, i) {
-							count++
-							if count%2 == 1 {
-								tempInMultiLine = !tempInMultiLine
-							}
-							i += 2 // 跳过这个三引号
+			}
+			
+			if !singleLineDocstring {
+				// 计算不在字符串内的三引号数量
+				count := 0
+				for i := 0; i <= len(line)-3; i++ {
+					if line[i:i+3] == "'''" && !isInQuoteString(line, i) {
+						count++
+						if count%2 == 1 {
+							tempInMultiLine = !tempInMultiLine
 						}
+						i += 2 // 跳过这个三引号
 					}
 				}
 			}
+		}
+		
+		// 如果这一行开始时在多行字符串中，整行都应该被保护
+		// 如果这一行结束了多行字符串，需要处理字符串结束后的注释
+		if !singleLineDocstring && s.inMultiLineString && !tempInMultiLine {
+			// 多行字符串在这一行结束，需要找到结束位置并处理后面的注释
+			var endPos int = -1
+			if strings.Contains(line, `"""`) {
+				endPos = strings.Index(line, `"""`) + 3
+			} else if strings.Contains(line, "'''") {
+				endPos = strings.Index(line, "'''") + 3
+			}
 			
-			// 如果这一行开始时在多行字符串中，整行都应该被保护
-			// 如果这一行结束了多行字符串，需要处理字符串结束后的注释
-			if !singleLineDocstring && inMultiLineString && !tempInMultiLine {
-				// 多行字符串在这一行结束，需要找到结束位置并处理后面的注释
-				var endPos int = -1
-				if strings.Contains(line, `"""`) {
-					endPos = strings.Index(line, `"""`) + 3
-				} else if strings.Contains(line, "'''") {
-					endPos = strings.Index(line, "'''") + 3
-				}
+			if endPos > 0 && endPos < len(line) {
+				// 多行字符串结束后还有内容，需要处理注释
+				beforeEnd := line[:endPos]
+				afterEnd := line[endPos:]
 				
-				if endPos > 0 && endPos < len(line) {
-					// 多行字符串结束后还有内容，需要处理注释
-					beforeEnd := line[:endPos]
-					afterEnd := line[endPos:]
-					
-					// 处理字符串结束后的部分
-					processedAfter := afterEnd
-					// 删除Python行注释
-					if pos := strings.Index(processedAfter, "#"); pos != -1 {
-						processedAfter = strings.TrimRight(processedAfter[:pos], " \t")
-					}
-					
-					processedLine = beforeEnd + processedAfter
+				// 处理字符串结束后的部分
+				processedAfter := afterEnd
+				// 删除Python行注释
+				if pos := strings.Index(processedAfter, "#"); pos != -1 {
+					processedAfter = strings.TrimRight(processedAfter[:pos], " \t")
 				}
+				
+				processedLine = beforeEnd + processedAfter
 			}
-			
-			inMultiLineString = tempInMultiLine
 		}
 		
-		// 如果之前在多行字符串中，跳过注释处理
-		if oldMultiLineState {
-			result = append(result, processedLine)
-			continue
-		}
-		
-		// 如果之前在反引号字符串中但现在不在，说明模板字符串结束了，需要处理外部注释
-		if oldBacktickState && !inBacktickString {
-			// 这行包含了模板字符串的结束，检查外部注释
-			if strings.Contains(line, "`") {
-				lastBacktick := -1
-				for i := len(line) - 1; i >= 0; i-- {
-					if line[i] == '`' && !isEscaped(line, i) {
-						lastBacktick = i
-						break
-					}
+		s.inMultiLineString = tempInMultiLine
+	}
+	
+	// 如果之前在多行字符串中，跳过注释处理
+	if oldMultiLineState {
+		return processedLine, true
+	}
+	
+	// 如果之前在反引号字符串中但现在不在，说明模板字符串结束了，需要处理外部注释
+	if oldBacktickState && !s.inBacktickString {
+		// 这行包含了模板字符串的结束，检查外部注释
+		if strings.Contains(line, "`") {
+			lastBacktick := -1
+			for i := len(line) - 1; i >= 0; i-- {
+				if line[i] == '`' && !isEscaped(line, i) {
+					lastBacktick = i
+					break
 				}
+			}
+			
+			if lastBacktick != -1 && lastBacktick < len(line)-1 {
+				afterBacktick := line[lastBacktick+1:]
 				
-				if lastBacktick != -1 && lastBacktick < len(line)-1 {
-					afterBacktick := line[lastBacktick+1:]
-					
-					// 检查是否有注释符号
-					for _, rule := range rules {
-						if rule.IsLineComment {
-							if pos := strings.Index(afterBacktick, rule.StartPattern); pos != -1 {
-								// 找到外部注释，删除它
-								beforeEnd := line[:lastBacktick+1]
-								afterEnd := afterBacktick[:pos]
-								processedLine = beforeEnd + strings.TrimRight(afterEnd, " \t")
-								break
-							}
+				// 检查是否有注释符号
+				for _, rule := range s.rules {
+					if rule.IsLineComment {
+						if pos := strings.Index(afterBacktick, rule.StartPattern); pos != -1 {
+							// 找到外部注释，删除它
+							beforeEnd := line[:lastBacktick+1]
+							afterEnd := afterBacktick[:pos]
+							processedLine = beforeEnd + strings.TrimRight(afterEnd, " \t")
+							break
 						}
 					}
 				}
 			}
 		}
-		
-		// 如果当前在反引号字符串中，跳过注释处理
-		if inBacktickString {
-			result = append(result, originalLine)
-			continue
+	}
+	
+	// 如果当前在反引号字符串中，跳过注释处理
+	if s.inBacktickString {
+		return originalLine, true
+	}
+	
+	
+	// 如果在块注释中
+	if s.inBlockComment {
+		if (s.fileType == "matlab" || s.fileType == "m") && strings.TrimSpace(line) != s.blockRule.EndPattern {
+			// MATLAB 的 %} 只有单独占一行时才结束块注释
+			return "", false
 		}
-		
-		
-		// 如果在块注释中
-		if inBlockComment {
-			if pos := strings.Index(processedLine, blockEndPattern); pos != -1 {
-				// 找到块注释结束，保留结束后的内容
-				afterComment := processedLine[pos+len(blockEndPattern):]
-				inBlockComment = false
-				
-				// 如果结束后还有内容，继续处理这部分内容
-				if strings.TrimSpace(afterComment) != "" {
-					// 递归处理剩余内容
-					remaining := removeCommentsByRules(afterComment, fileType, rules)
-					result = append(result, remaining)
-				}
-				// 如果结束后没有内容，跳过这一行（不添加空行）
+		end, depth := findBlockCommentEnd(processedLine, s.blockRule, s.blockDepth)
+		s.blockDepth = depth
+		if end != -1 {
+			// 找到块注释结束，保留结束后的内容
+			afterComment := processedLine[end:]
+			s.inBlockComment = false
+			
+			// 如果结束后还有内容，继续处理这部分内容
+			if strings.TrimSpace(afterComment) != "" {
+				// 递归处理剩余内容（沿用当前状态，剩余内容中可能开始新的多行字符串）
+				remaining, _ := s.processLine(afterComment)
+				return remaining, true
 			}
-			// 整行都在块注释中，跳过这一行（不添加空行）
+			// 如果结束后没有内容，跳过这一行（不添加空行）
+		}
+		// 整行都在块注释中，跳过这一行（不添加空行）
+		return "", false
+	}
+	
+	// 原始字符串和多行字符串中的内容替换为占位符后再查找注释
+	searchLine, checkLine := processedLine, originalLine
+	ranges := s.scanStrings(processedLine)
+	if isJSFileType(s.fileType) {
+		// 正则表达式字面量中的 // 和引号既不是注释也不是字符串
+		ranges = append(ranges, jsRegexRanges(processedLine)...)
+	}
+	if len(ranges) > 0 {
+		searchLine = maskRanges(processedLine, ranges)
+		checkLine = searchLine
+	}
+	
+	// 处理行注释和块注释：使用最早开始的注释，同一位置有多个标记匹配时（如 Lua 的 -- 和 --[[）使用最长的标记
+	pos := -1
+	var rule CommentRule
+	for _, candidate := range s.rules {
+		start, matched := s.findCommentStart(candidate, processedLine, originalLine, searchLine, checkLine)
+		if start == -1 {
 			continue
 		}
+		if pos == -1 || start < pos || (start == pos && len(matched.StartPattern) > len(rule.StartPattern)) {
+			pos, rule = start, matched
+		}
+	}
+	if pos != -1 && rule.IsLineComment {
+		beforeComment := processedLine[:pos]
+		// 如果注释前只有空白字符，则整行都是注释
+		if strings.TrimSpace(beforeComment) == "" {
+			processedLine = "" // 整行注释，变成空行
+		} else {
+			// 删除注释但去除尾部空格
+			processedLine = strings.TrimRight(beforeComment, " \t")
+		}
+	} else if pos != -1 {
+		beforeComment := processedLine[:pos]
 		
-		// 处理行注释和块注释
-		for _, rule := range rules {
-			if rule.IsLineComment {
-				// 处理行注释：需要找到第一个不在字符串内的注释符号
-				pos := -1
-				// YAML特殊处理：区分结构性注释和普通注释
-				if fileType == "yaml" || fileType == "yml" {
-					// 遍历所有可能的#位置
-					for i := 0; i <= len(processedLine)-len(rule.StartPattern); i++ {
-						if strings.HasPrefix(processedLine[i:], rule.StartPattern) {
-							// 检查是否在字符串内
-							if isInAnyString(processedLine, i) {
-								continue
-							}
-							
-							beforeComment := processedLine[:i]
-							// 如果#前只有空白字符，这是行首注释，检查是否为结构性注释
-							if strings.TrimSpace(beforeComment) == "" {
-								// 行首注释，检查是否需要保护（只保护结构性注释）
-								if shouldProtectInContext(originalLine, i, fileType, rule.StartPattern) {
-									pos = -1 // 保护结构性注释，不删除
-									break
-								} else {
-									pos = i // 删除普通注释
-									break
-								}
-							} else {
-								// 行尾注释，检查是否需要保护（Shell变量等）
-								if !shouldProtectInContext(originalLine, i, fileType, rule.StartPattern) {
-									pos = i
-									break
-								}
-							}
-						}
+		// 检查同一行是否有结束标记
+		bodyStart := pos + len(rule.StartPattern)
+		if endPos, depth := findBlockCommentEnd(processedLine[bodyStart:], rule, 1); endPos != -1 {
+			// 同一行内的块注释
+			actualEndPos := bodyStart + endPos
+			afterComment := processedLine[actualEndPos:]
+			
+			// 块注释之后可能还有注释，和跨行块注释结束时一样递归处理剩余内容
+			if strings.TrimSpace(afterComment) != "" {
+				*s = lineState
+				remaining, keep := s.processLine(afterComment)
+				if !keep {
+					// 剩余内容只有注释
+					remaining = ""
+					beforeComment = strings.TrimRight(beforeComment, " \t")
+				}
+				afterComment = remaining
+			}
+			
+			// 对于XML/HTML注释，不添加额外空格
+			replacement := ""
+			if s.fileType != "xml" && s.fileType != "html" && s.fileType != "htm" {
+				// 智能处理空格：只在需要时添加空格
+				needSpace := false
+				if len(beforeComment) > 0 && len(afterComment) > 0 {
+					lastCharBefore := beforeComment[len(beforeComment)-1]
+					firstCharAfter := afterComment[0]
+					if lastCharBefore != ' ' && lastCharBefore != '\t' && 
+					   firstCharAfter != ' ' && firstCharAfter != '\t' && firstCharAfter != '\n' {
+						needSpace = true
 					}
+				}
+				
+				if needSpace {
+					replacement = " "
+				}
+			}
+			
+			processedLine = beforeComment + replacement + afterComment
+		} else {
+			// 跨行块注释开始
+			if strings.TrimSpace(beforeComment) == "" {
+				processedLine = "" // 整行注释，变成空行
+			} else {
+				// 保持原有的尾随空格，如果没有则添加一个
+				if strings.HasSuffix(beforeComment, " ") || strings.HasSuffix(beforeComment, "\t") {
+					processedLine = beforeComment
 				} else {
-					// 其他语言的原有逻辑
-					for i := 0; i <= len(processedLine)-len(rule.StartPattern); i++ {
-						if strings.HasPrefix(processedLine[i:], rule.StartPattern) {
-							// 检查是否在字符串内（包括原始字符串和正则表达式）
-							if !isInAnyString(originalLine, i) && !isInRegex(originalLine, i) {
-								// 检查是否需要保护
-								protected := shouldProtectInContext(originalLine, i, fileType, rule.StartPattern)
-								if !protected {
-									pos = i
-									break
-								}
-							}
-						}
-					}
+					processedLine = beforeComment + " "
 				}
-				// 如果找到了注释位置，处理注释删除
-				if pos != -1 {
-					beforeComment := processedLine[:pos]
-					// 如果注释前只有空白字符，则整行都是注释
+			}
+			s.inBlockComment = true
+			s.blockRule = rule
+			s.blockDepth = depth
+		}
+	}
+	
+	// 如果处理后的行是空的且原始行不是空的，跳过这一行
+	if strings.TrimSpace(processedLine) == "" && strings.TrimSpace(originalLine) != "" {
+		return "", false
+	}
+	
+	return processedLine, true
+}
+
+// findCommentStart 查找一条规则在行中的注释开始位置，没有时返回 -1
+// 返回的规则是实际匹配的标记（Lua 长括号注释的开始和结束标记取决于等号个数）
+func (s *commentStripper) findCommentStart(rule CommentRule, processedLine, originalLine, searchLine, checkLine string) (int, CommentRule) {
+	if rule.IsLineComment {
+		// 处理行注释：需要找到第一个不在字符串内的注释符号
+		pos := -1
+		// YAML特殊处理：区分结构性注释和普通注释
+		if s.fileType == "yaml" || s.fileType == "yml" {
+			// 遍历所有可能的#位置
+			for i := 0; i <= len(processedLine)-len(rule.StartPattern); i++ {
+				if strings.HasPrefix(processedLine[i:], rule.StartPattern) {
+					// 检查是否在字符串内
+					if isInAnyString(processedLine, i) {
+						continue
+					}
+					
+					beforeComment := processedLine[:i]
+					// 如果#前只有空白字符，这是行首注释，检查是否为结构性注释
 					if strings.TrimSpace(beforeComment) == "" {
-						processedLine = "" // 整行注释，变成空行
+						// 行首注释，检查是否需要保护（只保护结构性注释）
+						if shouldProtectInContext(originalLine, i, s.fileType, rule.StartPattern) {
+							pos = -1 // 保护结构性注释，不删除
+							break
+						} else {
+							pos = i // 删除普通注释
+							break
+						}
 					} else {
-						// 删除注释但去除尾部空格
-						processedLine = strings.TrimRight(beforeComment, " \t")
+						// 行尾注释，检查是否需要保护（Shell变量等）
+						if !shouldProtectInContext(originalLine, i, s.fileType, rule.StartPattern) {
+							pos = i
+							break
+						}
 					}
-					break
 				}
-			} else {
-				// 处理块注释
-				if pos := strings.Index(processedLine, rule.StartPattern); pos != -1 {
-					if !shouldProtectInContext(originalLine, pos, fileType, rule.StartPattern) && 
-					   !isInAnyString(originalLine, pos) && !isInBacktickString(originalLine, pos) && !isInRegex(originalLine, pos) {
-						beforeComment := processedLine[:pos]
-						
-						// 检查同一行是否有结束标记
-						if endPos := strings.Index(processedLine[pos:], rule.EndPattern); endPos != -1 {
-							// 同一行内的块注释
-							actualEndPos := pos + endPos + len(rule.EndPattern)
-							afterComment := processedLine[actualEndPos:]
-							
-							// 对于XML/HTML注释，不添加额外空格
-							replacement := ""
-							if fileType != "xml" && fileType != "html" && fileType != "htm" {
-								// 智能处理空格：只在需要时添加空格
-								needSpace := false
-								if len(beforeComment) > 0 && len(afterComment) > 0 {
-									lastCharBefore := beforeComment[len(beforeComment)-1]
-									firstCharAfter := afterComment[0]
-									if lastCharBefore != ' ' && lastCharBefore != '\t' && 
-									   firstCharAfter != ' ' && firstCharAfter != '\t' && firstCharAfter != '\n' {
-										needSpace = true
-									}
-								}
-								
-								if needSpace {
-									replacement = " "
-								}
-							}
-							
-							processedLine = beforeComment + replacement + afterComment
-						} else {
-							// 跨行块注释开始
-							if strings.TrimSpace(beforeComment) == "" {
-								processedLine = "" // 整行注释，变成空行
-							} else {
-								// 保持原有的尾随空格，如果没有则添加一个
-								if strings.HasSuffix(beforeComment, " ") || strings.HasSuffix(beforeComment, "\t") {
-									processedLine = beforeComment
-								} else {
-									processedLine = beforeComment + " "
-								}
-							}
-							inBlockComment = true
-							blockEndPattern = rule.EndPattern
+			}
+		} else if start, ok := s.wordCommentStart(searchLine); ok {
+			// Shell 和 PowerShell：# 只在单词开头时开始注释，引号、参数展开和命令替换中的 # 不是注释
+			pos = start
+		} else {
+			// 其他语言的原有逻辑
+			for i := 0; i <= len(searchLine)-len(rule.StartPattern); i++ {
+				if strings.HasPrefix(searchLine[i:], rule.StartPattern) {
+					// 检查是否在字符串内（包括原始字符串和正则表达式）
+					if !isInStringForLanguage(checkLine, i, StringTypeAll, s.fileType) && !isInRegexForLanguage(checkLine, i, s.fileType) {
+						// 检查是否需要保护
+						protected := shouldProtectInContext(checkLine, i, s.fileType, rule.StartPattern)
+						if !protected {
+							pos = i
+							break
 						}
-						break
 					}
 				}
 			}
 		}
-		
-		// 如果处理后的行是空的且原始行不是空的，跳过这一行
-		if strings.TrimSpace(processedLine) == "" && strings.TrimSpace(originalLine) != "" {
+		return pos, rule
+	}
+	
+	// 块注释：受保护的开始标记（字符串中的标记、SQL 优化器提示等）之后继续查找
+	for from := 0; ; {
+		i := strings.Index(searchLine[from:], rule.StartPattern)
+		if i == -1 {
+			return -1, rule
+		}
+		pos := from + i
+		from = pos + len(rule.StartPattern)
+		if shouldProtectInContext(checkLine, pos, s.fileType, rule.StartPattern) ||
+			isInStringForLanguage(checkLine, pos, StringTypeAll, s.fileType) || isInRegexForLanguage(checkLine, pos, s.fileType) {
 			continue
 		}
-		
-		result = append(result, processedLine)
+		if rule.LongBracket {
+			level, ok := longBracketLevel(searchLine, pos+len(rule.StartPattern)-1)
+			if !ok {
+				continue
+			}
+			rule.StartPattern += strings.Repeat("=", level) + "["
+			rule.EndPattern = "]" + strings.Repeat("=", level) + "]"
+		}
+		return pos, rule
+	}
+}
+
+// findBlockCommentEnd 在 text 中查找块注释的结束位置（结束标记之后），depth 为进入 text 时的嵌套深度
+// 可嵌套的注释遇到开始标记时深度加一；未找到结束时返回 -1 和剩余的深度
+func findBlockCommentEnd(text string, rule CommentRule, depth int) (int, int) {
+	if !rule.Nested {
+		if pos := strings.Index(text, rule.EndPattern); pos != -1 {
+			return pos + len(rule.EndPattern), 0
+		}
+		return -1, depth
+	}
+	for i := 0; i < len(text); {
+		switch {
+		case strings.HasPrefix(text[i:], rule.EndPattern):
+			depth--
+			i += len(rule.EndPattern)
+			if depth == 0 {
+				return i, 0
+			}
+		case strings.HasPrefix(text[i:], rule.StartPattern):
+			depth++
+			i += len(rule.StartPattern)
+		default:
+			i++
+		}
+	}
+	return -1, depth
+}
+
+// removeCommentsByRules 根据注释规则删除注释
+func removeCommentsByRules(content string, fileType string, rules []CommentRule) string {
+	lines := strings.Split(content, "\n")
+	result := make([]string, 0, len(lines))
+	stripper := newCommentStripper(fileType, rules)
+
+	for _, line := range lines {
+		if processed, keep := stripper.processLine(line); keep {
+			result = append(result, processed)
+		}
 	}
 	
 	return strings.Join(result, "\n")
@@ -814,6 +1077,22 @@ func removeCommentsByFileType(content, fileType string) string {
 
 // removeComments 移除指定文件类型的注释
 func removeComments(content, fileType string) string {
+	// notebook 是 JSON 结构，需要解析后按单元格处理
+	if fileType == "ipynb" {
+		return removeNotebookComments(content)
+	}
+	// PowerShell 可以保留基于注释的帮助块
+	if fileType == "ps1" {
+		return removePowerShellComments(content)
+	}
+	// JSX 文本不是代码，需要定位 JSX 元素后只处理其中的表达式
+	if isJSXFileType(fileType) {
+		return removeJSXComments(content, fileType)
+	}
+	// HTML 和单文件组件按区域处理内嵌的脚本和样式
+	if isMarkupFileType(fileType) {
+		return removeMarkupComments(content, fileType)
+	}
 	// 统一使用规则处理所有文件类型
 	return removeCommentsByFileType(content, fileType)
 }
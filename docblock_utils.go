@@ -0,0 +1,50 @@
+package main
+
+import "strings"
+
+// isRubyFileType 检查文件类型是否为 Ruby
+func isRubyFileType(fileType string) bool {
+	return fileType == "ruby" || fileType == "rb"
+}
+
+// isPerlFileType 检查文件类型是否为 Perl
+func isPerlFileType(fileType string) bool {
+	return fileType == "perl" || fileType == "pl" || fileType == "pm"
+}
+
+// hasLineDirective 检查行是否以指令开头（指令之后是空白或行尾），如 =begin、=end、=cut
+func hasLineDirective(line, directive string) bool {
+	if !strings.HasPrefix(line, directive) {
+		return false
+	}
+	rest := line[len(directive):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r'
+}
+
+// docBlockStart 检查一行是否开始一个行首标记的文档块，返回结束指令和是否原样保留
+// Ruby 的 =begin ... =end 块注释；Perl 的 POD（=pod、=head1 等任意 =指令 ... =cut），设置 --keep-pod 时保留
+func docBlockStart(fileType, line string) (string, bool, bool) {
+	switch {
+	case isRubyFileType(fileType):
+		if hasLineDirective(line, "=begin") {
+			return "=end", false, true
+		}
+	case isPerlFileType(fileType):
+		if len(line) > 1 && line[0] == '=' && ((line[1] >= 'a' && line[1] <= 'z') || (line[1] >= 'A' && line[1] <= 'Z')) {
+			return "=cut", keepPerlPOD, true
+		}
+	}
+	return "", false, false
+}
+
+// isDataSectionMarker 检查一行是否为数据段的开始（Ruby 的 __END__，Perl 的 __END__ 和 __DATA__），之后的内容不是代码
+func isDataSectionMarker(fileType, line string) bool {
+	marker := strings.TrimRight(line, " \t\r")
+	switch {
+	case isRubyFileType(fileType):
+		return marker == "__END__"
+	case isPerlFileType(fileType):
+		return marker == "__END__" || marker == "__DATA__"
+	}
+	return false
+}
@@ -1,8 +1,9 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -74,6 +75,7 @@ var supportedExtensions = map[string]bool{
 	".R":     true,
 	".jl":    true,
 	".nb":    true,
+	".ipynb": true,
 	
 	".html":  true,
 	".htm":   true,
@@ -213,6 +215,14 @@ func isFileSafe(filePath string, content []byte, force bool) error {
 		return fmt.Errorf("文件 %s 是二进制文件，跳过处理", filePath)
 	}
 	
+	// notebook 输出中的图片等数据通常是很长的单行，只检查 JSON 是否有效
+	if strings.ToLower(filepath.Ext(filePath)) == ".ipynb" {
+		if !json.Valid(content) {
+			return fmt.Errorf("文件 %s 不是有效的 notebook，跳过处理", filePath)
+		}
+		return nil
+	}
+	
 	// 检查行长度
 	lines := strings.Split(string(content), "\n")
 	for i, line := range lines {
@@ -225,17 +235,31 @@ func isFileSafe(filePath string, content []byte, force bool) error {
 }
 
 // initBackupDir 初始化备份根目录
-func initBackupDir(workingDir string) {
+func initBackupDir(workingDir string) error {
 	if backupRootDir == "" {
 		dirName := filepath.Base(workingDir)
 		backupRootDir = filepath.Join(workingDir, "bak", dirName+"_"+backupTimestamp)
+		
+		// 记录运行开始，用于中断后续传和回滚
+		absDir, err := filepath.Abs(workingDir)
+		if err != nil {
+			return fmt.Errorf("计算工作目录失败: %v", err)
+		}
+		return appendJournalEntry(backupRootDir, journalEntry{Event: JournalStart, Root: absDir})
 	}
+	return nil
 }
 
 // createBackup 创建文件备份，保持目录结构
 func createBackup(filePath, workingDir string) error {
+	if noBackup {
+		return nil
+	}
+	
 	// 初始化备份根目录
-	initBackupDir(workingDir)
+	if err := initBackupDir(workingDir); err != nil {
+		return err
+	}
 	
 	// 计算相对路径
 	relPath, err := filepath.Rel(workingDir, filePath)
@@ -252,54 +276,108 @@ func createBackup(filePath, workingDir string) error {
 		return fmt.Errorf("创建备份目录失败: %v", err)
 	}
 	
-	// 读取原文件内容
-	content, err := os.ReadFile(filePath)
+	info, err := os.Stat(filePath)
 	if err != nil {
-		return fmt.Errorf("读取文件失败: %v", err)
+		return fmt.Errorf("读取文件信息失败: %v", err)
 	}
 	
-	// 写入备份文件
-	err = ioutil.WriteFile(backupPath, content, 0644)
-	if err != nil {
+	// 以流的方式复制原文件，并保留权限、属主等元数据
+	if err := copyFileWithMetadata(backupPath, filePath, info); err != nil {
 		return fmt.Errorf("创建备份失败: %v", err)
 	}
 	
-	return nil
+	return recordJournal(JournalBackup, relPath)
+}
+
+// detectHeadSize 歧义扩展名检测时最多读取的字节数
+const detectHeadSize = 64 * 1024
+
+// readFileHead 读取文件开头最多 n 个字节，避免检测大文件类型时整体载入内存
+func readFileHead(filePath string, n int) ([]byte, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	
+	buf := make([]byte, n)
+	read, err := io.ReadFull(f, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+// ambiguousExtensions 需要根据文件内容判断语言的歧义扩展名
+var ambiguousExtensions = map[string]bool{
+	".m":   true,
+	".r":   true,
+	".s":   true,
+	".d":   true,
+	".f":   true,
+	".pro": true,
+	".pl":  true,
+	".v":   true,
 }
 
 // detectFileType 检测文件的真实类型，处理歧义扩展名
 func detectFileType(filePath string) string {
+	var content []byte
 	ext := strings.ToLower(filepath.Ext(filePath))
+	if ambiguousExtensions[ext] {
+		head, err := readFileHead(filePath, detectHeadSize)
+		if err != nil {
+			return "unknown"
+		}
+		content = head
+	} else if ext == ".sql" {
+		// 根据内容检测 SQL 方言，无法读取时按标准 SQL 处理
+		content, _ = readFileHead(filePath, detectHeadSize)
+	}
+	return detectFileTypeFromContent(filePath, content)
+}
+
+// detectFileTypeFromContent 根据文件名和内容检测文件类型，用于不在磁盘上的内容（归档条目、git 过滤器）
+func detectFileTypeFromContent(name string, content []byte) string {
+	ext := strings.ToLower(filepath.Ext(name))
 	
 	switch ext {
 	case ".m":
-		return detectMFileType(filePath)
+		return detectMFileType(content)
 	case ".r":
-		return detectRFileType(filePath)
+		return detectRFileType(content)
 	case ".s":
-		return detectSFileType(filePath)
+		return detectSFileType(content)
 	case ".d":
-		return detectDFileType(filePath)
+		return detectDFileType(content)
 	case ".f":
-		return detectFFileType(filePath)
+		return detectFFileType(content)
 	case ".pro":
-		return detectProFileType(filePath)
+		return detectProFileType(content)
 	case ".pl":
-		return detectPlFileType(filePath)
+		return detectPlFileType(content)
 	case ".v":
-		return detectVFileType(filePath)
+		return detectVFileType(content)
 	case ".md", ".markdown":
 		return "markdown"
 	case ".yml", ".yaml":
 		return "yaml"
 	case ".json", ".jsonc", ".json5":
 		return "json"
-	case ".xml", ".html", ".htm", ".svg":
+	case ".sql":
+		return detectSQLFileType(content)
+	case ".ipynb":
+		return "ipynb"
+	case ".html", ".htm":
+		return "html"
+	case ".xml", ".svg":
 		return "xml"
 	case ".css", ".scss", ".sass", ".less":
 		return "css"
 	case ".rs":
 		return "rust"
+	case ".mjs", ".cjs":
+		return "js"
 	case ".shader", ".hlsl", ".glsl":
 		return "c"
 	default:
@@ -308,12 +386,7 @@ func detectFileType(filePath string) string {
 }
 
 // detectMFileType 区分 .m 文件是 Objective-C 还是 MATLAB
-func detectMFileType(filePath string) string {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "unknown"
-	}
-	
+func detectMFileType(content []byte) string {
 	// 限制检查前1000字节以提高性能
 	if len(content) > 1000 {
 		content = content[:1000]
@@ -348,12 +421,7 @@ func detectMFileType(filePath string) string {
 }
 
 // detectRFileType 检测 R 语言文件
-func detectRFileType(filePath string) string {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "unknown"
-	}
-	
+func detectRFileType(content []byte) string {
 	if len(content) > 500 {
 		content = content[:500]
 	}
@@ -371,12 +439,7 @@ func detectRFileType(filePath string) string {
 }
 
 // detectSFileType 区分 .s 文件类型
-func detectSFileType(filePath string) string {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "unknown"
-	}
-	
+func detectSFileType(content []byte) string {
 	if len(content) > 200 {
 		content = content[:200]
 	}
@@ -390,12 +453,7 @@ func detectSFileType(filePath string) string {
 }
 
 // detectDFileType 检测 D 语言文件
-func detectDFileType(filePath string) string {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "unknown"
-	}
-	
+func detectDFileType(content []byte) string {
 	if strings.Contains(string(content), "import std.") {
 		return "d"
 	}
@@ -403,27 +461,45 @@ func detectDFileType(filePath string) string {
 	return "unknown"
 }
 
-// detectFFileType 检测 Fortran 文件
-func detectFFileType(filePath string) string {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "unknown"
+// detectFFileType 检测 Fortran 文件，固定格式返回 fortran，按自由格式书写的 .f 文件返回 f90
+func detectFFileType(content []byte) string {
+	isFortran, freeForm := false, false
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || isFortranFixedComment(line) {
+			continue
+		}
+		// 语句从前 5 列开始（标号除外）或以 & 续行的是自由格式
+		trimmed := strings.TrimLeft(line, " ")
+		if indent := len(line) - len(trimmed); indent < 5 && trimmed[0] != '\t' && (trimmed[0] < '0' || trimmed[0] > '9') && trimmed[0] != '!' && trimmed[0] != '#' {
+			freeForm = true
+		}
+		if strings.HasSuffix(line, "&") && !strings.HasPrefix(trimmed, "&") {
+			freeForm = true
+		}
+		
+		// 程序单元和声明关键字
+		words := strings.Fields(strings.ToUpper(strings.NewReplacer("(", " ", ",", " ").Replace(trimmed)))
+		for i, word := range words {
+			if word == "PROGRAM" || word == "MODULE" || word == "SUBROUTINE" || word == "FUNCTION" ||
+				(i == 0 && (word == "IMPLICIT" || word == "USE")) {
+				isFortran = true
+				break
+			}
+		}
 	}
 	
-	if strings.Contains(strings.ToUpper(string(content)), "PROGRAM") {
-		return "fortran"
+	if !isFortran {
+		return "unknown"
 	}
-	
-	return "unknown"
+	if freeForm {
+		return "f90"
+	}
+	return "fortran"
 }
 
 // detectProFileType 区分 .pro 文件类型
-func detectProFileType(filePath string) string {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "unknown"
-	}
-	
+func detectProFileType(content []byte) string {
 	contentStr := strings.ToLower(string(content))
 	if strings.Contains(contentStr, "qt") || strings.Contains(contentStr, "target") {
 		return "qmake"
@@ -436,12 +512,7 @@ func detectProFileType(filePath string) string {
 }
 
 // detectPlFileType 区分 .pl 文件类型
-func detectPlFileType(filePath string) string {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "unknown"
-	}
-	
+func detectPlFileType(content []byte) string {
 	contentStr := string(content)
 	if strings.Contains(contentStr, "#!/usr/bin/perl") || strings.Contains(contentStr, "use strict") {
 		return "perl"
@@ -454,12 +525,7 @@ func detectPlFileType(filePath string) string {
 }
 
 // detectPpFileType 区分 .pp 文件类型
-func detectPpFileType(filePath string) string {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "unknown"
-	}
-	
+func detectPpFileType(content []byte) string {
 	contentStr := strings.ToLower(string(content))
 	if strings.Contains(contentStr, "program") || strings.Contains(contentStr, "begin") {
 		return "pascal"
@@ -472,12 +538,7 @@ func detectPpFileType(filePath string) string {
 }
 
 // detectVFileType 检测 Verilog 文件
-func detectVFileType(filePath string) string {
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return "unknown"
-	}
-	
+func detectVFileType(content []byte) string {
 	contentStr := strings.ToLower(string(content))
 	
 	// Verilog 关键字
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// pkt-line 协议常量
+const (
+	pktMaxPayload = 65516 // 单个数据包最大负载
+	pktHeaderSize = 4     // 长度头（四位十六进制，包含自身）
+)
+
+// errPktFlush 读到 flush 包（0000）
+var errPktFlush = errors.New("flush packet")
+
+// readPktLine 读取一个 pkt-line 数据包，遇到 flush 包时返回 errPktFlush
+func readPktLine(r io.Reader) ([]byte, error) {
+	var header [pktHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	length, err := strconv.ParseUint(string(header[:]), 16, 16)
+	if err != nil {
+		return nil, fmt.Errorf("无效的 pkt-line 长度: %q", header)
+	}
+	if length == 0 {
+		return nil, errPktFlush
+	}
+	if length < pktHeaderSize || length > pktMaxPayload+pktHeaderSize {
+		return nil, fmt.Errorf("无效的 pkt-line 长度: %d", length)
+	}
+	payload := make([]byte, length-pktHeaderSize)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// writePktLine 写入一个 pkt-line 数据包
+func writePktLine(w io.Writer, payload []byte) error {
+	if len(payload) > pktMaxPayload {
+		return fmt.Errorf("pkt-line 负载过大: %d", len(payload))
+	}
+	if _, err := fmt.Fprintf(w, "%04x", len(payload)+pktHeaderSize); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// writePktFlush 写入 flush 包
+func writePktFlush(w io.Writer) error {
+	_, err := io.WriteString(w, "0000")
+	return err
+}
+
+// writePktText 写入以换行结尾的文本包
+func writePktText(w io.Writer, text string) error {
+	return writePktLine(w, []byte(text+"\n"))
+}
+
+// readPktTextList 读取直到 flush 包的一组文本包（去掉行尾换行）
+func readPktTextList(r io.Reader) ([]string, error) {
+	var list []string
+	for {
+		payload, err := readPktLine(r)
+		if err == errPktFlush {
+			return list, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, strings.TrimSuffix(string(payload), "\n"))
+	}
+}
+
+// readPktContent 读取直到 flush 包的内容数据
+func readPktContent(r io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	for {
+		payload, err := readPktLine(r)
+		if err == errPktFlush {
+			return buf.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(payload)
+	}
+}
+
+// writePktContent 把内容拆分成多个数据包写出，并以 flush 包结束
+func writePktContent(w io.Writer, content []byte) error {
+	for len(content) > 0 {
+		n := len(content)
+		if n > pktMaxPayload {
+			n = pktMaxPayload
+		}
+		if err := writePktLine(w, content[:n]); err != nil {
+			return err
+		}
+		content = content[n:]
+	}
+	return writePktFlush(w)
+}
+
+// filterContent 按路径名和 git 传入的内容检测语言并删除注释（路径名不一定能在当前目录下读取）；不支持或不安全的内容原样返回
+func filterContent(command, pathname string, content []byte) []byte {
+	if command != "clean" {
+		// smudge 时保留仓库中的内容，不做处理
+		return content
+	}
+	if !isSupportedFile(pathname, false) || isFileSafe(pathname, content, false) != nil {
+		return content
+	}
+	fileType := detectFileTypeFromContent(pathname, content)
+	if fileType == "unknown" {
+		return content
+	}
+	return []byte(removeComments(string(content), fileType))
+}
+
+// filterHandshake 完成 long-running filter 协议的版本和能力协商
+func filterHandshake(r io.Reader, w *bufio.Writer) error {
+	welcome, err := readPktTextList(r)
+	if err != nil {
+		return fmt.Errorf("读取握手信息失败: %v", err)
+	}
+	if len(welcome) == 0 || welcome[0] != "git-filter-client" {
+		return fmt.Errorf("无效的握手信息: %v", welcome)
+	}
+	supportsV2 := false
+	for _, line := range welcome[1:] {
+		if line == "version=2" {
+			supportsV2 = true
+		}
+	}
+	if !supportsV2 {
+		return fmt.Errorf("git 不支持 filter 协议版本 2")
+	}
+	for _, text := range []string{"git-filter-server", "version=2"} {
+		if err := writePktText(w, text); err != nil {
+			return err
+		}
+	}
+	if err := writePktFlush(w); err != nil {
+		return err
+	}
+	// 必须先把版本响应发出去，git 收到后才会发送能力列表
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	capabilities, err := readPktTextList(r)
+	if err != nil {
+		return fmt.Errorf("读取能力列表失败: %v", err)
+	}
+	for _, capability := range capabilities {
+		if capability == "capability=clean" || capability == "capability=smudge" {
+			if err := writePktText(w, capability); err != nil {
+				return err
+			}
+		}
+	}
+	return writePktFlush(w)
+}
+
+// runFilterProcess 实现 git 的 long-running filter process 协议（filter.<driver>.process）
+func runFilterProcess(in io.Reader, out io.Writer) error {
+	r := bufio.NewReader(in)
+	w := bufio.NewWriter(out)
+
+	if err := filterHandshake(r, w); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for {
+		headers, err := readPktTextList(r)
+		if err == io.EOF {
+			// git 关闭管道，正常结束
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var command, pathname string
+		for _, header := range headers {
+			key, value, _ := strings.Cut(header, "=")
+			switch key {
+			case "command":
+				command = value
+			case "pathname":
+				pathname = value
+			}
+		}
+
+		content, err := readPktContent(r)
+		if err != nil {
+			return err
+		}
+
+		if command != "clean" && command != "smudge" {
+			if err := writePktText(w, "status=error"); err != nil {
+				return err
+			}
+			if err := writePktFlush(w); err != nil {
+				return err
+			}
+		} else {
+			result := filterContent(command, pathname, content)
+			if err := writePktText(w, "status=success"); err != nil {
+				return err
+			}
+			if err := writePktFlush(w); err != nil {
+				return err
+			}
+			if err := writePktContent(w, result); err != nil {
+				return err
+			}
+			// 空列表表示沿用之前的 success 状态
+			if err := writePktFlush(w); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+}
+
+// runSingleFilter 单次调用的过滤模式（filter.<driver>.clean = "fuck-comment filter clean %f"），从 stdin 读取、写入 stdout
+func runSingleFilter(command, pathname string, in io.Reader, out io.Writer) error {
+	if command != "clean" && command != "smudge" {
+		return fmt.Errorf("未知的过滤命令: %s（可选 clean|smudge）", command)
+	}
+	content, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(filterContent(command, pathname, content))
+	return err
+}
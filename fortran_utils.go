@@ -0,0 +1,96 @@
+package main
+
+import "strings"
+
+// isFortranFileType 检查文件类型是否为 Fortran
+func isFortranFileType(fileType string) bool {
+	return isFortranFixedForm(fileType) || fileType == "f90" || fileType == "f95"
+}
+
+// isFortranFixedForm 检查 Fortran 文件是否为固定格式（.f、.for），.f90、.f95 为自由格式
+func isFortranFixedForm(fileType string) bool {
+	return fileType == "fortran" || fileType == "f" || fileType == "for"
+}
+
+// isFortranFixedComment 检查固定格式的一行是否为注释行（第 1 列为 C、c、* 或 !）
+func isFortranFixedComment(line string) bool {
+	return line != "" && strings.IndexByte("Cc*!", line[0]) != -1
+}
+
+// fortranStatementStart 返回固定格式一行中语句区的开始位置和该行是否为续行
+// 第 1-5 列是标号，第 6 列不是空格或 0 时为续行；行首的 tab 之后是语句区，tab 之后的 1-9 表示续行
+func fortranStatementStart(line string) (int, bool) {
+	for i := 0; i < len(line) && i < 6; i++ {
+		if line[i] != '\t' {
+			continue
+		}
+		if i+1 < len(line) && line[i+1] >= '1' && line[i+1] <= '9' {
+			return i + 2, true
+		}
+		return i + 1, false
+	}
+	if len(line) < 6 {
+		return len(line), false
+	}
+	return 6, line[5] != ' ' && line[5] != '0'
+}
+
+// fortranCommentStart 返回 Fortran 一行中 ! 注释开始的位置（没有时为 -1）和行尾仍未结束的字符串引号
+// quote 是上一行延续到本行的字符串引号；字符串中连续两个引号表示引号本身，交替开关即可正确处理
+func fortranCommentStart(line string, fixed bool, quote byte) (int, byte) {
+	start := 0
+	if fixed {
+		var continued bool
+		start, continued = fortranStatementStart(line)
+		if !continued {
+			quote = 0
+		}
+	} else if quote != 0 {
+		// 自由格式的字符串续行可以用 & 开头，之后继续字符串
+		trimmed := strings.TrimLeft(line, " \t")
+		if strings.HasPrefix(trimmed, "&") {
+			start = len(line) - len(trimmed) + 1
+		}
+	}
+
+	for i := start; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '!':
+			return i, 0
+		}
+	}
+
+	// 自由格式的字符串只有在行尾是 & 时才延续到下一行
+	if !fixed && !strings.HasSuffix(strings.TrimRight(line, " \t\r"), "&") {
+		quote = 0
+	}
+	return -1, quote
+}
+
+// processFortranLine 处理 Fortran 的一行：固定格式按列判断注释行，! 注释只在字符串之外生效
+// 删除注释不会移动语句区的列位置
+func (s *commentStripper) processFortranLine(line string) (string, bool) {
+	fixed := isFortranFixedForm(s.fileType)
+	if fixed && isFortranFixedComment(line) {
+		// 注释行可以出现在续行之间，不影响字符串状态
+		return "", false
+	}
+
+	pos, quote := fortranCommentStart(line, fixed, s.fortranQuote)
+	s.fortranQuote = quote
+	if pos == -1 {
+		return line, true
+	}
+	before := strings.TrimRight(line[:pos], " \t")
+	if strings.TrimSpace(before) == "" {
+		return "", false
+	}
+	return before, true
+}
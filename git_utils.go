@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Git 文件选择模式
+const (
+	GitSelectStaged  = "staged"  // 暂存区中的文件
+	GitSelectChanged = "changed" // 相对基准提交有变化的文件
+	GitSelectTracked = "tracked" // 所有已跟踪的文件
+)
+
+// gitSelector 根据命令行参数返回 Git 文件选择模式，未启用时返回空字符串
+func gitSelector() (string, error) {
+	var selected []string
+	if gitStaged {
+		selected = append(selected, GitSelectStaged)
+	}
+	if gitChangedBase != "" {
+		selected = append(selected, GitSelectChanged)
+	}
+	if gitTracked {
+		selected = append(selected, GitSelectTracked)
+	}
+	if len(selected) > 1 {
+		return "", fmt.Errorf("--git-staged、--git-changed、--git-tracked 只能指定一个")
+	}
+	if len(selected) == 0 {
+		return "", nil
+	}
+	return selected[0], nil
+}
+
+// runGit 在指定目录执行本地 git 命令并返回标准输出
+func runGit(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s 失败: %s", strings.Join(args, " "), msg)
+		}
+		return nil, fmt.Errorf("git %s 失败: %v", strings.Join(args, " "), err)
+	}
+	return out, nil
+}
+
+// gitRepoRoot 获取目录所在 Git 仓库的根目录
+func gitRepoRoot(dir string) (string, error) {
+	out, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return resolveRealPath(strings.TrimSpace(string(out)))
+}
+
+// listGitFiles 按选择模式列出文件，返回相对仓库根目录的路径（已删除的文件不包含在内）
+func listGitFiles(repoRoot, selector, baseRef string) ([]string, error) {
+	var args []string
+	switch selector {
+	case GitSelectStaged:
+		args = []string{"diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z"}
+	case GitSelectChanged:
+		// 以 - 开头的参数会被 git 当作选项
+		if strings.HasPrefix(baseRef, "-") {
+			return nil, fmt.Errorf("无效的基准提交: %s", baseRef)
+		}
+		args = []string{"diff", "--name-only", "--diff-filter=ACMR", "-z", baseRef, "--"}
+	case GitSelectTracked:
+		args = []string{"ls-files", "--cached", "-z"}
+	default:
+		return nil, fmt.Errorf("未知的 Git 选择模式: %s", selector)
+	}
+
+	out, err := runGit(repoRoot, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(string(out), "\x00") {
+		// ls-files 在合并冲突时会重复列出同一路径
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+// isHiddenOrBackupPath 检查相对路径中是否包含隐藏目录、隐藏文件或备份目录，与目录遍历的跳过规则一致
+func isHiddenOrBackupPath(relPath string) bool {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+		if part == "bak" && i < len(parts)-1 {
+			return true
+		}
+	}
+	return false
+}
+
+// processGitFiles 只处理 Git 选择出的文件，而不是遍历整个目录
+func processGitFiles(rootDir, selector, baseRef string) error {
+	tracker, err := newLinkTracker(rootDir)
+	if err != nil {
+		return err
+	}
+	repoRoot, err := gitRepoRoot(tracker.root)
+	if err != nil {
+		return err
+	}
+	files, err := listGitFiles(repoRoot, selector, baseRef)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range files {
+		if interrupted.Load() {
+			return errInterrupted
+		}
+
+		path := filepath.Join(repoRoot, filepath.FromSlash(name))
+		// 只处理目标目录内的文件
+		if !isWithinRoot(path, tracker.root) {
+			continue
+		}
+		relPath, _ := filepath.Rel(tracker.root, path)
+		if isHiddenOrBackupPath(relPath) {
+			continue
+		}
+
+		info, err := os.Lstat(path)
+		if err != nil {
+			// 工作区中已删除的文件
+			continue
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if err := handleSymlink(tracker, path); err != nil {
+				return err
+			}
+			continue
+		}
+		if !info.Mode().IsRegular() || !isSupportedFile(path, forceMode) {
+			continue
+		}
+
+		processTrackedFile(tracker, path)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Hook 运行模式
+const (
+	HookModeCheck = "check" // 只检查，发现注释时阻止提交
+	HookModeStrip = "strip" // 删除注释并重新暂存
+)
+
+// hookMarker 用于识别由本工具安装的 hook 脚本
+const hookMarker = "# installed by fuck-comment"
+
+// preCommitHooksYAML pre-commit 框架使用的 hook 定义（.pre-commit-hooks.yaml）
+const preCommitHooksYAML = `- id: fuck-comment
+  name: fuck-comment (strip comments)
+  description: 删除提交文件中的注释
+  entry: fuck-comment hook run --mode strip
+  language: golang
+  types: [text]
+- id: fuck-comment-check
+  name: fuck-comment (check comments)
+  description: 检查提交文件中是否包含注释
+  entry: fuck-comment hook run --mode check
+  language: golang
+  types: [text]
+`
+
+// validateHookMode 检查 hook 运行模式是否合法
+func validateHookMode(mode string) error {
+	if mode != HookModeCheck && mode != HookModeStrip {
+		return fmt.Errorf("无效的 hook 模式: %s（可选 check|strip）", mode)
+	}
+	return nil
+}
+
+// preCommitHookPath 获取 pre-commit hook 路径（遵循 core.hooksPath 配置）
+func preCommitHookPath(dir string) (string, error) {
+	out, err := runGit(dir, "rev-parse", "--git-path", "hooks/pre-commit")
+	if err != nil {
+		return "", err
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path, nil
+}
+
+// preCommitHookScript 生成 pre-commit hook 脚本
+func preCommitHookScript(executable, mode string) string {
+	return "#!/bin/sh\n" +
+		hookMarker + "\n" +
+		"exec \"" + executable + "\" hook run --mode " + mode + "\n"
+}
+
+// installPreCommitHook 安装 pre-commit hook，已存在其他 hook 时需要 --overwrite 才会覆盖
+func installPreCommitHook(dir, mode string, overwrite bool) (string, error) {
+	hookPath, err := preCommitHookPath(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if existing, err := os.ReadFile(hookPath); err == nil && !strings.Contains(string(existing), hookMarker) && !overwrite {
+		return "", fmt.Errorf("已存在其他 pre-commit hook: %s（使用 --overwrite 覆盖）", hookPath)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("获取可执行文件路径失败: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0755); err != nil {
+		return "", fmt.Errorf("创建 hooks 目录失败: %v", err)
+	}
+	if err := os.WriteFile(hookPath, []byte(preCommitHookScript(executable, mode)), 0755); err != nil {
+		return "", fmt.Errorf("写入 hook 失败: %v", err)
+	}
+	// WriteFile 不会修改已存在文件的权限
+	return hookPath, os.Chmod(hookPath, 0755)
+}
+
+// uninstallPreCommitHook 删除由本工具安装的 pre-commit hook
+func uninstallPreCommitHook(dir string) (string, error) {
+	hookPath, err := preCommitHookPath(dir)
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(hookPath)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("没有安装 pre-commit hook")
+	}
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(string(content), hookMarker) {
+		return "", fmt.Errorf("%s 不是由 fuck-comment 安装的，未删除", hookPath)
+	}
+	return hookPath, os.Remove(hookPath)
+}
+
+// writePreCommitHooksYAML 在仓库根目录写入 .pre-commit-hooks.yaml
+func writePreCommitHooksYAML(dir string) (string, error) {
+	path := filepath.Join(dir, ".pre-commit-hooks.yaml")
+	return path, os.WriteFile(path, []byte(preCommitHooksYAML), 0644)
+}
+
+// hookCandidateFiles 返回 hook 要处理的文件（相对仓库根目录）
+// 未指定文件时使用暂存区中的文件
+func hookCandidateFiles(repoRoot string, files []string) ([]string, error) {
+	if len(files) == 0 {
+		return listGitFiles(repoRoot, GitSelectStaged, "")
+	}
+	return files, nil
+}
+
+// hookFileContent 读取 hook 要检查的内容：暂存模式读取暂存区版本，显式文件读取工作区版本
+func hookFileContent(repoRoot, name string, fromIndex bool) ([]byte, error) {
+	if fromIndex {
+		return runGit(repoRoot, "show", ":"+filepath.ToSlash(name))
+	}
+	return os.ReadFile(filepath.Join(repoRoot, name))
+}
+
+// runHook 执行 hook：check 模式报告含注释的文件，strip 模式删除注释并重新暂存
+// files 为空时处理暂存区文件（git hook），否则处理指定文件（pre-commit 框架）
+// 返回是否应阻止提交
+func runHook(repoRoot, mode string, files []string) (bool, error) {
+	fromIndex := len(files) == 0
+	candidates, err := hookCandidateFiles(repoRoot, files)
+	if err != nil {
+		return false, err
+	}
+
+	// 部分暂存的文件无法安全地重新暂存
+	unstaged := make(map[string]bool)
+	if fromIndex && mode == HookModeStrip {
+		out, err := runGit(repoRoot, "diff", "--name-only", "-z")
+		if err != nil {
+			return false, err
+		}
+		for _, name := range strings.Split(string(out), "\x00") {
+			unstaged[name] = true
+		}
+	}
+
+	var offending, restage []string
+	for _, name := range candidates {
+		path := filepath.Join(repoRoot, filepath.FromSlash(name))
+		info, err := os.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() || isHiddenOrBackupPath(name) || !isSupportedFile(path, forceMode) {
+			continue
+		}
+
+		content, err := hookFileContent(repoRoot, name, fromIndex)
+		if err != nil {
+			return false, err
+		}
+		if isFileSafe(name, content, forceMode) != nil {
+			continue
+		}
+		fileType := detectFileTypeFromContent(name, content)
+		if fileType == "unknown" || removeComments(string(content), fileType) == string(content) {
+			continue
+		}
+
+		if mode == HookModeCheck {
+			printWarning("%s 包含注释", name)
+			offending = append(offending, name)
+			continue
+		}
+		if unstaged[filepath.ToSlash(name)] {
+			printError("%s 有未暂存的修改，请先暂存或储藏后再提交", name)
+			offending = append(offending, name)
+			continue
+		}
+		if err := processFile(path, repoRoot); err != nil {
+			return false, err
+		}
+		restage = append(restage, name)
+	}
+
+	if fromIndex && len(restage) > 0 {
+		if _, err := runGit(repoRoot, append([]string{"add", "--"}, restage...)...); err != nil {
+			return false, err
+		}
+		printSuccess("已删除注释并重新暂存 %d 个文件", len(restage))
+	}
+	// pre-commit 框架模式下修改了文件需要返回失败，由框架提示用户重新暂存
+	if !fromIndex && len(restage) > 0 {
+		return true, nil
+	}
+	return len(offending) > 0, nil
+}
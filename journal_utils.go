@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// journalFileName 备份快照中的运行日志文件名（隐藏文件，不会与备份的源文件冲突）
+const journalFileName = ".fuck-comment-journal"
+
+// 运行日志事件类型
+const (
+	JournalStart       = "start"       // 运行开始
+	JournalResume      = "resume"      // 续传运行开始
+	JournalBackup      = "backup"      // 文件已备份
+	JournalWritten     = "written"     // 文件已写入处理结果
+	JournalFinish      = "finish"      // 运行正常结束
+	JournalInterrupted = "interrupted" // 运行被中断
+)
+
+// errInterrupted 运行被信号中断
+var errInterrupted = errors.New("运行被中断")
+
+var (
+	// interrupted 收到中断信号后置位，处理完当前文件后停止
+	interrupted atomic.Bool
+
+	// resumedFiles 续传时已完成写入的文件（相对路径）
+	resumedFiles map[string]bool
+)
+
+// journalEntry 运行日志中的一条记录
+type journalEntry struct {
+	Event string `json:"event"`
+	Path  string `json:"path,omitempty"`
+	Root  string `json:"root,omitempty"`
+	Time  string `json:"time"`
+}
+
+// setupInterruptHandler 注册中断信号处理：第一次信号在当前文件完成后停止，第二次立即退出
+func setupInterruptHandler() {
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		interrupted.Store(true)
+		printWarning("收到中断信号，处理完当前文件后停止（再次按 Ctrl-C 立即退出）")
+		<-sigCh
+		os.Exit(130)
+	}()
+}
+
+// journalPath 返回当前备份快照的运行日志路径
+func journalPath(snapshotDir string) string {
+	return filepath.Join(snapshotDir, journalFileName)
+}
+
+// recordJournal 向当前快照的运行日志追加一条记录并刷盘
+func recordJournal(event, relPath string) error {
+	if backupRootDir == "" {
+		return nil
+	}
+	return appendJournalEntry(backupRootDir, journalEntry{Event: event, Path: filepath.ToSlash(relPath)})
+}
+
+// appendJournalEntry 追加日志记录，每条记录单独刷盘以便在崩溃后仍可读取
+func appendJournalEntry(snapshotDir string, entry journalEntry) error {
+	if err := os.MkdirAll(snapshotDir, 0755); err != nil {
+		return fmt.Errorf("创建备份目录失败: %v", err)
+	}
+	entry.Time = time.Now().Format(time.RFC3339)
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(journalPath(snapshotDir), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("写入运行日志失败: %v", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("写入运行日志失败: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("写入运行日志失败: %v", err)
+	}
+	return f.Close()
+}
+
+// loadJournal 读取快照的运行日志
+func loadJournal(snapshotDir string) ([]journalEntry, error) {
+	f, err := os.Open(journalPath(snapshotDir))
+	if err != nil {
+		return nil, fmt.Errorf("读取运行日志失败: %v", err)
+	}
+	defer f.Close()
+
+	var entries []journalEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry journalEntry
+		// 崩溃时最后一行可能不完整，忽略无法解析的行
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// finishJournal 记录运行结束状态（仅在本次运行创建了快照时）
+func finishJournal(event string) {
+	if backupRootDir == "" {
+		return
+	}
+	if _, err := os.Stat(journalPath(backupRootDir)); err != nil {
+		return
+	}
+	if err := recordJournal(event, ""); err != nil {
+		printWarning("%v", err)
+	}
+}
+
+// isJournalComplete 检查运行日志是否记录了正常结束
+func isJournalComplete(entries []journalEntry) bool {
+	return len(entries) > 0 && entries[len(entries)-1].Event == JournalFinish
+}
+
+// listSnapshots 列出工作目录下的备份快照，按时间从新到旧排序
+func listSnapshots(workingDir string) ([]string, error) {
+	pattern := filepath.Join(workingDir, "bak", filepath.Base(workingDir)+"_*")
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && info.IsDir() {
+			snapshots = append(snapshots, match)
+		}
+	}
+	// 目录名以时间戳结尾，字典序即时间顺序
+	sort.Sort(sort.Reverse(sort.StringSlice(snapshots)))
+	return snapshots, nil
+}
+
+// prepareResume 查找最近一次未完成的运行，复用其备份快照并加载已完成的文件
+func prepareResume(workingDir string) error {
+	// 备份快照位于解析符号链接后的真实目录下
+	workingDir, err := resolveRealPath(workingDir)
+	if err != nil {
+		return err
+	}
+	snapshots, err := listSnapshots(workingDir)
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		entries, err := loadJournal(snapshot)
+		if err != nil || isJournalComplete(entries) {
+			continue
+		}
+
+		resumedFiles = make(map[string]bool)
+		for _, entry := range entries {
+			if entry.Event == JournalWritten {
+				resumedFiles[entry.Path] = true
+			}
+		}
+		backupRootDir = snapshot
+		printInfo("继续未完成的运行: %s（已完成 %d 个文件）", snapshot, len(resumedFiles))
+		return appendJournalEntry(snapshot, journalEntry{Event: JournalResume, Root: workingDir})
+	}
+	return fmt.Errorf("没有找到可继续的未完成运行")
+}
+
+// isResumedFile 检查文件是否已在被中断的运行中完成
+func isResumedFile(filePath, workingDir string) bool {
+	if resumedFiles == nil {
+		return false
+	}
+	relPath, err := filepath.Rel(workingDir, filePath)
+	if err != nil {
+		return false
+	}
+	return resumedFiles[filepath.ToSlash(relPath)]
+}
+
+// restoreSnapshot 根据运行日志把快照中备份的文件恢复到原位置
+func restoreSnapshot(snapshotDir string) error {
+	entries, err := loadJournal(snapshotDir)
+	if err != nil {
+		return err
+	}
+
+	var root string
+	restored := make(map[string]bool)
+	for _, entry := range entries {
+		if (entry.Event == JournalStart || entry.Event == JournalResume) && entry.Root != "" {
+			root = entry.Root
+		}
+		if entry.Event != JournalBackup || restored[entry.Path] {
+			continue
+		}
+		if root == "" {
+			return fmt.Errorf("运行日志缺少工作目录信息")
+		}
+
+		relPath := filepath.FromSlash(entry.Path)
+		backupPath := filepath.Join(snapshotDir, relPath)
+		info, err := os.Stat(backupPath)
+		if err != nil {
+			printError("备份文件不存在: %s", backupPath)
+			continue
+		}
+
+		target := filepath.Join(root, relPath)
+		if err := copyFileWithMetadata(target, backupPath, info); err != nil {
+			printError("恢复 %s 失败: %v", relPath, err)
+			continue
+		}
+		restored[entry.Path] = true
+		fmt.Printf("%s "+ColorGreen+"已恢复"+ColorReset+"\n", relPath)
+	}
+
+	printSuccess("共恢复 %d 个文件", len(restored))
+	return nil
+}
+
+// resolveSnapshot 解析 restore 命令的快照参数，未指定时使用当前目录下最近的快照
+func resolveSnapshot(args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	workingDir, err := resolveRealPath(".")
+	if err != nil {
+		return "", err
+	}
+	snapshots, err := listSnapshots(workingDir)
+	if err != nil {
+		return "", err
+	}
+	for _, snapshot := range snapshots {
+		if _, err := os.Stat(journalPath(snapshot)); err == nil {
+			return snapshot, nil
+		}
+	}
+	return "", fmt.Errorf("在 %s 下没有找到备份快照", filepath.Join(workingDir, "bak"))
+}
+
+// describeSnapshotStatus 返回快照运行状态的描述
+func describeSnapshotStatus(entries []journalEntry) string {
+	if isJournalComplete(entries) {
+		return "已完成"
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Event == JournalInterrupted {
+			return "已中断"
+		}
+	}
+	return "未完成"
+}
@@ -0,0 +1,424 @@
+package main
+
+import "strings"
+
+// isJSXFileType 检查文件类型是否包含 JSX 语法
+func isJSXFileType(fileType string) bool {
+	return fileType == "jsx" || fileType == "tsx"
+}
+
+// jsxRangeKind JSX 扫描得到的区域类型
+type jsxRangeKind int
+
+const (
+	jsxVerbatim jsxRangeKind = iota // JSX 标签和文本，原样保留
+	jsxComment                      // {/* */} 注释表达式，整体删除
+)
+
+// jsxRange 源码中的一段 JSX 区域，未被区域覆盖的部分是普通 JS 代码
+type jsxRange struct {
+	start, end int
+	kind       jsxRangeKind
+}
+
+// jsxScanner 在 JS/TS 源码中定位 JSX 元素，区分 JSX 文本、表达式中的代码和注释表达式
+type jsxScanner struct {
+	src    string
+	pos    int
+	ranges []jsxRange
+
+	// 当前未结束的原样保留区域起点，-1 表示不在 JSX 中
+	verbatimStart int
+}
+
+// jsxPrefixKeywords 之后可以出现 JSX 元素的关键字
+var jsxPrefixKeywords = map[string]bool{
+	"return":  true,
+	"yield":   true,
+	"await":   true,
+	"default": true,
+	"case":    true,
+	"else":    true,
+	"do":      true,
+}
+
+// regexPrefixKeywords 之后的 / 是正则表达式而不是除号的关键字
+var regexPrefixKeywords = map[string]bool{
+	"return":     true,
+	"typeof":     true,
+	"instanceof": true,
+	"in":         true,
+	"of":         true,
+	"new":        true,
+	"delete":     true,
+	"void":       true,
+	"throw":      true,
+	"case":       true,
+	"do":         true,
+	"else":       true,
+	"yield":      true,
+	"await":      true,
+}
+
+// isJSIdentChar 检查字符是否可以出现在 JS 标识符中
+func isJSIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// beginVerbatim 开始一段原样保留区域（已在区域中时不做处理）
+func (s *jsxScanner) beginVerbatim(pos int) {
+	if s.verbatimStart == -1 {
+		s.verbatimStart = pos
+	}
+}
+
+// endVerbatim 结束当前原样保留区域
+func (s *jsxScanner) endVerbatim(pos int) {
+	if s.verbatimStart != -1 && pos > s.verbatimStart {
+		s.ranges = append(s.ranges, jsxRange{start: s.verbatimStart, end: pos, kind: jsxVerbatim})
+	}
+	s.verbatimStart = -1
+}
+
+// skipQuoted 跳过以 quote 结尾的字符串（pos 位于开始引号之后），escapes 表示是否处理反斜杠转义
+func (s *jsxScanner) skipQuoted(quote byte, escapes bool) {
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		s.pos++
+		if escapes && c == '\\' {
+			s.pos++
+			continue
+		}
+		if c == quote || (escapes && c == '\n') {
+			return
+		}
+	}
+}
+
+// skipRegex 跳过正则表达式字面量（pos 位于开始的 / 之后）
+func (s *jsxScanner) skipRegex() {
+	inClass := false
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		s.pos++
+		switch {
+		case c == '\\':
+			s.pos++
+		case c == '\n':
+			return
+		case c == '[':
+			inClass = true
+		case c == ']':
+			inClass = false
+		case c == '/' && !inClass:
+			return
+		}
+	}
+}
+
+// scanCode 扫描 JS 代码直到文件结束；nested 为 true 时遇到不匹配的 } 返回（pos 停在 } 上）
+func (s *jsxScanner) scanCode(nested bool) {
+	depth := 0
+	var lastSig byte // 上一个有效字符
+	lastWord := ""   // 上一个有效记号为标识符时的内容
+
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			s.pos++
+			continue
+		case strings.HasPrefix(s.src[s.pos:], "//"):
+			if end := strings.IndexByte(s.src[s.pos:], '\n'); end != -1 {
+				s.pos += end
+			} else {
+				s.pos = len(s.src)
+			}
+			continue
+		case strings.HasPrefix(s.src[s.pos:], "/*"):
+			if end := strings.Index(s.src[s.pos+2:], "*/"); end != -1 {
+				s.pos += end + 4
+			} else {
+				s.pos = len(s.src)
+			}
+			continue
+		case c == '\'' || c == '"':
+			s.pos++
+			s.skipQuoted(c, true)
+		case c == '`':
+			s.pos++
+			s.scanTemplate()
+		case c == '/' && regexAllowedAfter(lastSig, lastWord):
+			s.pos++
+			s.skipRegex()
+		case c == '<' && s.jsxAllowed(lastSig, lastWord):
+			start, saved := s.pos, len(s.ranges)
+			s.beginVerbatim(start)
+			if s.scanElement() {
+				s.endVerbatim(s.pos)
+			} else {
+				// 不是 JSX（如 TSX 中的泛型 <T,>），按普通代码继续
+				s.ranges = s.ranges[:saved]
+				s.verbatimStart = -1
+				s.pos = start + 1
+				lastSig, lastWord = '<', ""
+				continue
+			}
+		case isJSIdentChar(c):
+			start := s.pos
+			for s.pos < len(s.src) && isJSIdentChar(s.src[s.pos]) {
+				s.pos++
+			}
+			lastWord = s.src[start:s.pos]
+			// 标识符之后的 / 是除号，< 是比较或泛型
+			lastSig = 'a'
+			if regexPrefixKeywords[lastWord] || jsxPrefixKeywords[lastWord] {
+				lastSig = ' '
+			}
+			continue
+		case c == '{':
+			depth++
+			s.pos++
+		case c == '}':
+			if depth == 0 && nested {
+				return
+			}
+			depth--
+			s.pos++
+		default:
+			s.pos++
+		}
+		lastSig = s.src[s.pos-1]
+		if c == '\'' || c == '"' || c == '`' || c == '/' || c == '<' {
+			// 字符串、正则表达式和 JSX 元素之后相当于一个值
+			lastSig = 'a'
+		}
+		lastWord = ""
+	}
+}
+
+// jsxAllowed 根据前一个记号判断 < 是否开始一个 JSX 元素
+func (s *jsxScanner) jsxAllowed(lastSig byte, lastWord string) bool {
+	if s.pos+1 >= len(s.src) {
+		return false
+	}
+	next := s.src[s.pos+1]
+	if next != '>' && !(next >= 'a' && next <= 'z') && !(next >= 'A' && next <= 'Z') {
+		return false
+	}
+	if lastSig == 'a' {
+		return false
+	}
+	if lastSig == ' ' {
+		return jsxPrefixKeywords[lastWord]
+	}
+	if lastSig == '>' {
+		// 只允许箭头函数 => 之后的 JSX
+		return s.pos >= 2 && strings.HasSuffix(strings.TrimRight(s.src[:s.pos], " \t\r\n"), "=>")
+	}
+	return lastSig == 0 || strings.IndexByte("(,=:[!&|?{};", lastSig) != -1
+}
+
+// scanTemplate 扫描模板字符串（pos 位于开始的反引号之后），${} 中的代码按 JS 扫描
+func (s *jsxScanner) scanTemplate() {
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		s.pos++
+		switch {
+		case c == '\\':
+			s.pos++
+		case c == '`':
+			return
+		case c == '$' && s.pos < len(s.src) && s.src[s.pos] == '{':
+			s.pos++
+			s.scanCode(true)
+			s.pos++
+		}
+	}
+}
+
+// scanExpression 扫描 JSX 中的表达式容器（pos 位于 { 上）
+// 只包含块注释的 {/* */} 记录为删除区域，其余表达式中的代码按 JS 处理
+func (s *jsxScanner) scanExpression() bool {
+	open := s.pos
+	inner := strings.TrimLeft(s.src[open+1:], " \t\r\n")
+	if strings.HasPrefix(inner, "/*") {
+		commentStart := len(s.src) - len(inner)
+		if end := strings.Index(inner, "*/"); end != -1 {
+			after := commentStart + end + 2
+			rest := strings.TrimLeft(s.src[after:], " \t\r\n")
+			if strings.HasPrefix(rest, "}") {
+				closeBrace := len(s.src) - len(rest)
+				s.endVerbatim(open)
+				s.ranges = append(s.ranges, jsxRange{start: open, end: closeBrace + 1, kind: jsxComment})
+				s.pos = closeBrace + 1
+				s.beginVerbatim(s.pos)
+				return true
+			}
+		}
+	}
+
+	s.endVerbatim(open + 1)
+	s.pos = open + 1
+	s.scanCode(true)
+	if s.pos >= len(s.src) {
+		return false
+	}
+	s.beginVerbatim(s.pos)
+	s.pos++
+	return true
+}
+
+// scanElement 扫描一个 JSX 元素（pos 位于 < 上），成功时 pos 位于元素结束之后
+func (s *jsxScanner) scanElement() bool {
+	s.pos++
+	if s.pos < len(s.src) && s.src[s.pos] == '>' {
+		// 片段 <>...</>
+		s.pos++
+		return s.scanChildren()
+	}
+
+	nameStart := s.pos
+	for s.pos < len(s.src) && (isJSIdentChar(s.src[s.pos]) || strings.IndexByte(".:-", s.src[s.pos]) != -1) {
+		s.pos++
+	}
+	if s.pos == nameStart {
+		return false
+	}
+
+	// 标签属性
+	first := true
+	for s.pos < len(s.src) {
+		c := s.src[s.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			s.pos++
+		case strings.HasPrefix(s.src[s.pos:], "/>"):
+			s.pos += 2
+			return true
+		case c == '>':
+			s.pos++
+			return s.scanChildren()
+		case c == '{':
+			if !s.scanExpression() {
+				return false
+			}
+		case c == '"' || c == '\'':
+			s.pos++
+			s.skipQuoted(c, false)
+		case c == '=' || isJSIdentChar(c) || c == '-' || c == ':' || c == '.':
+			start := s.pos
+			for s.pos < len(s.src) && (isJSIdentChar(s.src[s.pos]) || strings.IndexByte("-:.", s.src[s.pos]) != -1) {
+				s.pos++
+			}
+			// TSX 泛型参数 <T extends U> 不是 JSX
+			if first && s.src[start:s.pos] == "extends" {
+				return false
+			}
+			first = false
+			if s.pos == start {
+				s.pos++
+			}
+		default:
+			// 其他字符（如泛型的 <T,>）说明不是 JSX 标签
+			return false
+		}
+	}
+	return false
+}
+
+// scanChildren 扫描 JSX 子节点直到对应的结束标签
+func (s *jsxScanner) scanChildren() bool {
+	for s.pos < len(s.src) {
+		switch s.src[s.pos] {
+		case '{':
+			if !s.scanExpression() {
+				return false
+			}
+		case '<':
+			if strings.HasPrefix(s.src[s.pos:], "</") {
+				end := strings.IndexByte(s.src[s.pos:], '>')
+				if end == -1 {
+					return false
+				}
+				s.pos += end + 1
+				return true
+			}
+			if !s.scanElement() {
+				return false
+			}
+		default:
+			s.pos++
+		}
+	}
+	return false
+}
+
+// stripCodeSegment 用共享的逐行处理器删除一段 JS 代码中的注释
+// startsLine、endsLine 表示片段首行是否从行首开始、末行是否到达文件结尾；
+// 与 JSX 同处一行的首尾两行被清空时保留为空片段，不能连同换行一起删除
+func stripCodeSegment(stripper *commentStripper, segment string, startsLine, endsLine bool) string {
+	lines := strings.Split(segment, "\n")
+	result := make([]string, 0, len(lines))
+	for i, line := range lines {
+		processed, keep := stripper.processLine(line)
+		if !keep && ((i == 0 && !startsLine) || (i == len(lines)-1 && !endsLine)) {
+			processed, keep = "", true
+		}
+		if keep {
+			result = append(result, processed)
+		}
+	}
+	return strings.Join(result, "\n")
+}
+
+// removeJSXComments 删除 JSX/TSX 源码中的注释：JS 代码按 JS/TS 规则处理，
+// JSX 文本（如 <p>// 不是注释</p>）原样保留，{/* */} 注释表达式连同大括号一起删除
+func removeJSXComments(content, fileType string) string {
+	scanner := &jsxScanner{src: content, verbatimStart: -1}
+	scanner.scanCode(false)
+	scanner.endVerbatim(len(content))
+
+	stripper := newCommentStripper(fileType, getCommentRulesForLanguage(fileType))
+	var result strings.Builder
+	skipBlankLine := false
+	emitVerbatim := func(text string) {
+		if skipBlankLine {
+			skipBlankLine = false
+			if nl := strings.IndexByte(text, '\n'); nl != -1 {
+				text = text[nl+1:]
+			}
+		}
+		result.WriteString(text)
+	}
+
+	pos := 0
+	for _, r := range scanner.ranges {
+		if r.start > pos {
+			result.WriteString(stripCodeSegment(stripper, content[pos:r.start], pos == 0 || content[pos-1] == '\n', false))
+		}
+		switch r.kind {
+		case jsxVerbatim:
+			emitVerbatim(content[r.start:r.end])
+		case jsxComment:
+			// 注释表达式独占一行时连同缩进和换行一起删除
+			out := result.String()
+			lineStart := strings.LastIndexByte(out, '\n') + 1
+			rest := content[r.end:]
+			if nl := strings.IndexByte(rest, '\n'); nl != -1 {
+				rest = rest[:nl]
+			}
+			if strings.TrimSpace(out[lineStart:]) == "" && strings.TrimSpace(rest) == "" {
+				result.Reset()
+				result.WriteString(out[:lineStart])
+				skipBlankLine = true
+			}
+		}
+		pos = r.end
+	}
+	if pos < len(content) {
+		result.WriteString(stripCodeSegment(stripper, content[pos:], pos == 0 || content[pos-1] == '\n', true))
+	}
+	return result.String()
+}
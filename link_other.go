@@ -0,0 +1,15 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// fileIdentity 当前平台无法从 FileInfo 获取 inode，不做硬链接去重
+func fileIdentity(info os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
+
+// linkCount 当前平台按单链接处理
+func linkCount(info os.FileInfo) uint64 {
+	return 1
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 符号链接处理策略
+const (
+	SymlinkSkip   = "skip"   // 跳过符号链接（默认）
+	SymlinkFollow = "follow" // 跟随符号链接，处理其目标
+	SymlinkError  = "error"  // 遇到符号链接时报错并停止
+)
+
+// errOutsideRoot 符号链接目标位于处理根目录之外
+var errOutsideRoot = errors.New("符号链接目标位于根目录之外")
+
+// fileID 唯一标识一个物理文件（设备号 + inode）
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// linkTracker 记录一次运行中已访问的目录和已处理的物理文件，用于循环检测和硬链接去重
+type linkTracker struct {
+	root        string // 真实根目录（已解析符号链接）
+	visitedDirs map[string]bool
+	seenFiles   map[fileID]string
+	seenPaths   map[string]string // 已处理文件的真实路径，原子替换使 inode 改变后仍能识别
+	hardlinks   map[fileID]*hardlinkGroup
+}
+
+// hardlinkGroup 一个有多个硬链接名的物理文件，names 为遍历中遇到的链接名数量
+type hardlinkGroup struct {
+	first string
+	nlink uint64
+	names uint64
+}
+
+// newLinkTracker 创建以 root 为边界的链接跟踪器
+func newLinkTracker(root string) (*linkTracker, error) {
+	realRoot, err := resolveRealPath(root)
+	if err != nil {
+		return nil, fmt.Errorf("解析目录失败: %v", err)
+	}
+	return &linkTracker{
+		root:        realRoot,
+		visitedDirs: make(map[string]bool),
+		seenFiles:   make(map[fileID]string),
+		seenPaths:   make(map[string]string),
+		hardlinks:   make(map[fileID]*hardlinkGroup),
+	}, nil
+}
+
+// validateSymlinkPolicy 检查符号链接策略参数是否合法
+func validateSymlinkPolicy(policy string) error {
+	switch policy {
+	case SymlinkSkip, SymlinkFollow, SymlinkError:
+		return nil
+	}
+	return fmt.Errorf("无效的符号链接策略: %s（可选 skip|follow|error）", policy)
+}
+
+// resolveRealPath 获取解析全部符号链接后的绝对路径
+func resolveRealPath(path string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(absPath)
+}
+
+// isWithinRoot 检查路径是否位于根目录内
+func isWithinRoot(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// resolveSymlink 解析符号链接目标，目标不在根目录内时返回 errOutsideRoot
+func (t *linkTracker) resolveSymlink(path string) (string, error) {
+	target, err := resolveRealPath(path)
+	if err != nil {
+		return "", fmt.Errorf("解析符号链接 %s 失败: %v", path, err)
+	}
+	if !isWithinRoot(target, t.root) {
+		return "", fmt.Errorf("%w: %s -> %s", errOutsideRoot, path, target)
+	}
+	return target, nil
+}
+
+// markFile 记录物理文件，若该文件（通过硬链接或符号链接）已处理过则返回 false 和首次出现的路径
+func (t *linkTracker) markFile(path string) (bool, string) {
+	// 处理过的文件已被原子替换为新的 inode，先按真实路径查找
+	realPath, err := resolveRealPath(path)
+	if err != nil {
+		return true, ""
+	}
+	if first, seen := t.seenPaths[realPath]; seen {
+		return false, first
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return true, ""
+	}
+	id, ok := fileIdentity(info)
+	if !ok {
+		return true, ""
+	}
+	if first, seen := t.seenFiles[id]; seen {
+		if group := t.hardlinks[id]; group != nil {
+			group.names++
+		}
+		t.seenPaths[realPath] = first
+		return false, first
+	}
+	t.seenFiles[id] = path
+	t.seenPaths[realPath] = path
+	if nlink := linkCount(info); nlink > 1 {
+		t.hardlinks[id] = &hardlinkGroup{first: path, nlink: nlink, names: 1}
+	}
+	return true, ""
+}
+
+// relinkHardlink 首个链接名已被原子替换为新文件时，把同一物理文件的另一个链接名 path 也原子地指向新文件
+// 返回是否重新链接（首个链接名没有变化时不需要）
+func relinkHardlink(path, first string) (bool, error) {
+	pathInfo, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	firstInfo, err := os.Stat(first)
+	if err != nil {
+		return false, err
+	}
+	if os.SameFile(pathInfo, firstInfo) {
+		return false, nil
+	}
+
+	// 先在同一目录创建指向新文件的临时链接，再通过 rename 原子替换
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".link*")
+	if err != nil {
+		return false, fmt.Errorf("创建临时文件失败: %v", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	os.Remove(tmpPath)
+	if err := os.Link(first, tmpPath); err != nil {
+		return false, fmt.Errorf("创建硬链接失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return false, err
+	}
+	return true, nil
+}
+
+// warnDetachedHardlinks 提示处理范围外仍指向原内容的硬链接名（原子替换只能重新链接遍历中遇到的链接名）
+func (t *linkTracker) warnDetachedHardlinks() {
+	for id, group := range t.hardlinks {
+		info, err := os.Stat(group.first)
+		if err != nil || group.names >= group.nlink {
+			continue
+		}
+		if current, ok := fileIdentity(info); ok && current != id {
+			printWarning("%s 还有 %d 个硬链接名不在处理范围内，仍指向原内容", group.first, group.nlink-group.names)
+		}
+	}
+}
+
+// resolveTargetFile 按符号链接策略解析 -f 指定的文件
+// 返回实际要写入的路径；返回空字符串表示按策略跳过
+func resolveTargetFile(path, workingDir string) (string, error) {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return path, nil
+	}
+
+	switch symlinkPolicy {
+	case SymlinkError:
+		return "", fmt.Errorf("指定的文件是符号链接: %s", path)
+	case SymlinkSkip:
+		skippedFiles = append(skippedFiles, path)
+		printWarning("跳过符号链接: %s（使用 --symlinks=follow 处理链接目标）", path)
+		return "", nil
+	}
+
+	tracker, err := newLinkTracker(workingDir)
+	if err != nil {
+		return "", err
+	}
+	target, err := tracker.resolveSymlink(path)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(tracker.root, target)
+	if err != nil {
+		return "", err
+	}
+	// 映射回工作目录下的路径，使备份目录结构与工作目录一致
+	return filepath.Join(workingDir, rel), nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -21,6 +22,24 @@ var (
 	targetFile string
 	forceMode  bool
 	showVersion bool
+	preserveMtime bool
+	symlinkPolicy string
+	resumeMode    bool
+	gitStaged      bool
+	gitChangedBase string
+	gitTracked     bool
+	hookMode       string
+	hookOverwrite  bool
+	noBackup       bool // 不创建备份（hook 模式下暂存区已保存原始内容）
+	watchMode      bool
+	watchPoll      bool
+	outputDir      string // 镜像输出目录，设置后不修改源文件
+	copyAllFiles   bool
+	clearNotebookOutputs  bool // 清空 notebook 代码单元格的输出
+	clearNotebookMarkdown bool // 删除 notebook 的 markdown 单元格
+	keepPowerShellHelp    bool // 保留 PowerShell 基于注释的帮助（<# .SYNOPSIS #>）
+	sqlDialect            string // .sql 文件的 SQL 方言，auto 时根据内容检测
+	keepPerlPOD           bool   // 保留 Perl 的 POD 文档
 	
 	// 统计信息
 	processedFiles []string
@@ -29,6 +48,7 @@ var (
 	// 安全限制
 	maxFileSize = 100 * 1024 * 1024 // 100MB
 	maxLineLength = 50000           // 50K字符
+	streamThreshold int64 = 8 * 1024 * 1024 // 超过8MB的文件使用流式处理
 	
 	// 备份相关
 	backupTimestamp = time.Now().Format("20060102_150405")
@@ -37,7 +57,34 @@ var (
 
 // processFile 处理单个文件，删除其中的注释
 func processFile(filePath, workingDir string) error {
+	// 续传时跳过被中断的运行中已完成的文件
+	if isResumedFile(filePath, workingDir) {
+		relPath, _ := filepath.Rel(workingDir, filePath)
+		fmt.Printf("%s "+ColorCyan+"已完成（续传跳过）"+ColorReset+"\n", relPath)
+		return nil
+	}
+	
+	// 大文件走流式处理，避免整个文件载入内存（需要整体解析的文件类型除外）
+	// 类型检测可能读取文件开头的内容，每个文件只检测一次
+	fileType := ""
+	if info, err := os.Stat(filePath); err == nil && info.Size() > streamThreshold {
+		fileType = detectFileType(filePath)
+		if isStreamable(fileType) {
+			if fileType == "unknown" {
+				skippedFiles = append(skippedFiles, filePath)
+				printWarning("无法识别文件类型: %s", filePath)
+				copyToOutput(filePath, workingDir)
+				return nil
+			}
+			return processFileStream(filePath, workingDir, fileType)
+		}
+	}
+	
 	// 读取文件内容
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return fmt.Errorf("读取文件信息失败: %v", err)
+	}
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("读取文件失败: %v", err)
@@ -47,14 +94,18 @@ func processFile(filePath, workingDir string) error {
 	if err := isFileSafe(filePath, content, forceMode); err != nil {
 		skippedFiles = append(skippedFiles, filePath)
 		printWarning("%s", err.Error())
+		copyToOutput(filePath, workingDir)
 		return nil
 	}
 	
-	// 检测文件类型
-	fileType := detectFileType(filePath)
+	// 检测文件类型（大文件已在上面检测过）
+	if fileType == "" {
+		fileType = detectFileType(filePath)
+	}
 	if fileType == "unknown" {
 		skippedFiles = append(skippedFiles, filePath)
 		printWarning("无法识别文件类型: %s", filePath)
+		copyToOutput(filePath, workingDir)
 		return nil
 	}
 	
@@ -62,6 +113,21 @@ func processFile(filePath, workingDir string) error {
 	originalContent := string(content)
 	processedContent := removeComments(originalContent, fileType)
 	
+	// 镜像输出模式：写入输出目录，不修改源文件也不需要备份
+	if outputDir != "" {
+		if err := writeOutputFile(filePath, workingDir, []byte(processedContent), info); err != nil {
+			return fmt.Errorf("写入输出文件失败: %v", err)
+		}
+		processedFiles = append(processedFiles, filePath)
+		relPath, _ := filepath.Rel(workingDir, filePath)
+		if originalContent == processedContent {
+			fmt.Printf("%s "+ColorYellow+"|%s|"+ColorReset+" 无变化，已复制\n", relPath, strings.ToUpper(fileType))
+		} else {
+			fmt.Printf("%s "+ColorGreen+"|%s|"+ColorReset+" "+ColorGreen+"✓"+ColorReset+"\n", relPath, strings.ToUpper(fileType))
+		}
+		return nil
+	}
+	
 	// 检查是否有变化
 	if originalContent == processedContent {
 		// 无变化，不需要备份和写入
@@ -75,17 +141,20 @@ func processFile(filePath, workingDir string) error {
 		return fmt.Errorf("创建备份失败: %v", err)
 	}
 	
-	// 写入处理后的内容
-	err = os.WriteFile(filePath, []byte(processedContent), 0644)
+	// 写入处理后的内容（临时文件 + rename，保留原文件元数据）
+	err = writeFileAtomic(filePath, filePath, []byte(processedContent), info)
 	if err != nil {
 		return fmt.Errorf("写入文件失败: %v", err)
 	}
 	
 	// 记录处理的文件
 	processedFiles = append(processedFiles, filePath)
+	relPath, _ := filepath.Rel(workingDir, filePath)
+	if err := recordJournal(JournalWritten, relPath); err != nil {
+		return err
+	}
 	
 	// 显示处理结果
-	relPath, _ := filepath.Rel(workingDir, filePath)
 	fmt.Printf("%s "+ColorGreen+"|%s|"+ColorReset+" "+ColorGreen+"✓"+ColorReset+"\n", relPath, strings.ToUpper(fileType))
 	
 	return nil
@@ -93,39 +162,136 @@ func processFile(filePath, workingDir string) error {
 
 // processDirectory 递归处理目录中的所有支持文件
 func processDirectory(rootDir string) error {
-	return filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+	tracker, err := newLinkTracker(rootDir)
+	if err != nil {
+		return err
+	}
+	err = walkDirectory(tracker, tracker.root)
+	tracker.warnDetachedHardlinks()
+	return err
+}
+
+// walkDirectory 遍历目录，按符号链接策略处理链接，每个物理文件只处理一次
+func walkDirectory(tracker *linkTracker, dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 		
+		// 收到中断信号后不再开始处理新文件
+		if interrupted.Load() {
+			return errInterrupted
+		}
+		
 		// 跳过目录
 		if d.IsDir() {
-			// 跳过隐藏目录和备份目录
-			if strings.HasPrefix(d.Name(), ".") || d.Name() == "bak" {
+			// 跳过隐藏目录和备份目录，隐藏目录在 --copy-all 时原样复制到镜像输出目录
+			if path != dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "bak" || isOutputPath(path)) {
+				if strings.HasPrefix(d.Name(), ".") && !isOutputPath(path) {
+					if err := copyTreeToOutput(path, tracker.root); err != nil {
+						printError("复制 %s 失败: %v", path, err)
+					}
+				}
 				return fs.SkipDir
 			}
+			tracker.visitedDirs[path] = true
 			return nil
 		}
 		
-		// 跳过隐藏文件
+		// 跳过隐藏文件，--copy-all 时原样复制
 		if strings.HasPrefix(d.Name(), ".") {
+			if d.Type().IsRegular() {
+				copyToOutput(path, tracker.root)
+			}
 			return nil
 		}
 		
+		// 符号链接按策略处理
+		if d.Type()&fs.ModeSymlink != 0 {
+			return handleSymlink(tracker, path)
+		}
+		
 		// 检查是否为支持的文件类型
 		if !isSupportedFile(path, forceMode) {
+			copyToOutput(path, tracker.root)
 			return nil
 		}
 		
-		// 处理文件
-		if err := processFile(path, rootDir); err != nil {
-			printError("处理文件 %s 失败: %v", path, err)
-		}
-		
+		processTrackedFile(tracker, path)
 		return nil
 	})
 }
 
+// handleSymlink 根据 --symlinks 策略处理目录遍历中遇到的符号链接
+func handleSymlink(tracker *linkTracker, path string) error {
+	switch symlinkPolicy {
+	case SymlinkError:
+		return fmt.Errorf("遇到符号链接: %s", path)
+	case SymlinkSkip:
+		printInfo("跳过符号链接: %s", path)
+		return nil
+	}
+	
+	target, err := tracker.resolveSymlink(path)
+	if err != nil {
+		skippedFiles = append(skippedFiles, path)
+		printWarning("%v，跳过处理", err)
+		return nil
+	}
+	
+	info, err := os.Stat(target)
+	if err != nil {
+		printWarning("读取符号链接目标失败: %v", err)
+		return nil
+	}
+	
+	if info.IsDir() {
+		// 目标目录已遍历过（包括链接指向祖先目录的循环），不再重复进入
+		if tracker.visitedDirs[target] || isWithinRoot(filepath.Dir(path), target) {
+			printWarning("检测到符号链接循环，跳过: %s -> %s", path, target)
+			return nil
+		}
+		return walkDirectory(tracker, target)
+	}
+	
+	// 按链接名判断类型，处理时写入真实目标
+	if !isSupportedFile(path, forceMode) && !isSupportedFile(target, forceMode) {
+		return nil
+	}
+	processTrackedFile(tracker, target)
+	if outputDir != "" {
+		// 镜像输出中链接名也需要有对应的文件
+		copyOutputAlias(path, target, tracker.root)
+	}
+	return nil
+}
+
+// processTrackedFile 处理文件，已通过其他路径（硬链接或符号链接）处理过的物理文件将被跳过
+func processTrackedFile(tracker *linkTracker, path string) {
+	if first, seenAt := tracker.markFile(path); !first {
+		if outputDir != "" {
+			// 镜像输出只对同一物理文件去重删除注释的工作，每个路径都写入输出
+			copyOutputAlias(path, seenAt, tracker.root)
+			return
+		}
+		// 同一物理文件的首个链接名被替换后，其他硬链接名重新链接到新文件
+		relinked, err := relinkHardlink(path, seenAt)
+		switch {
+		case err != nil:
+			printError("重新链接 %s 失败: %v", path, err)
+		case relinked:
+			printInfo("%s 已重新链接到 %s", path, seenAt)
+		default:
+			printInfo("跳过 %s（与 %s 为同一文件）", path, seenAt)
+		}
+		return
+	}
+	
+	if err := processFile(path, tracker.root); err != nil {
+		printError("处理文件 %s 失败: %v", path, err)
+	}
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "fuck-comment [directory]",
 	Short: "删除代码注释的命令行工具",
@@ -141,18 +307,39 @@ var rootCmd = &cobra.Command{
 		"  `<!-- -->`   HTML注释 (HTML, XML等)\n\n" +
 		"安全特性：\n" +
 		"  • 自动备份到 bak/ 目录\n" +
+		"  • 原子写入，保留文件权限、属主和扩展属性\n" +
 		"  • 跳过二进制文件\n" +
+		"  • 大文件流式处理，内存占用与文件大小无关\n" +
 		"  • 保护字符串中的注释符号\n" +
 		"  • 保护URL锚点和Shell变量\n\n" +
 		"参数说明：\n" +
 		"  -f, --file string    指定要处理的单个文件\n" +
 		"      --force          强制处理所有文件类型（包括二进制文件）\n" +
+		"      --preserve-mtime 保留文件修改时间\n" +
+		"      --symlinks string 符号链接策略: skip|follow|error（默认 skip）\n" +
+		"      --resume         继续最近一次被中断的运行\n" +
+		"      --out dir        写入镜像输出目录，不修改源文件\n" +
+		"      --copy-all       镜像输出时原样复制不支持和跳过的文件\n" +
+		"      --watch          处理完成后持续监听并处理发生变化的文件\n" +
+		"      --watch-poll     监听时使用轮询而不是系统文件通知\n" +
+		"      --git-staged     只处理Git暂存区中的文件\n" +
+		"      --git-changed ref 只处理相对指定提交有变化的文件\n" +
+		"      --git-tracked    只处理Git已跟踪的文件\n" +
+		"      --nb-clear-outputs  清空 notebook 代码单元格的输出和执行计数\n" +
+		"      --nb-clear-markdown 删除 notebook 的 markdown 单元格\n" +
+		"      --keep-ps-help   保留 PowerShell 基于注释的帮助块\n" +
+		"      --sql-dialect string SQL 方言: auto|ansi|mysql|postgres|oracle（默认 auto）\n" +
+		"      --keep-pod       保留 Perl 的 POD 文档\n" +
 		"      --version        显示版本信息\n\n" +
 		"使用示例:\n" +
 		"  fuck-comment              删除当前目录所有支持文件的注释\n" +
 		"  fuck-comment /path/to/dir 删除指定目录及其子目录的注释\n" +
 		"  fuck-comment -f main.go   删除指定文件的注释\n" +
-		"  fuck-comment --force      强制处理所有文件类型\n\n" +
+		"  fuck-comment --force      强制处理所有文件类型\n" +
+		"  fuck-comment --resume     继续被中断的运行\n" +
+		"  fuck-comment --out ../public --copy-all  生成删除注释后的完整副本\n" +
+		"  fuck-comment --git-changed v1.0.0  只处理发布差异中的文件\n" +
+		"  fuck-comment restore      回滚最近一次运行修改的文件\n\n" +
 		"注意事项：\n" +
 		"  • 处理前会自动创建备份，备份文件保存在 bak/ 目录\n" +
 		"  • 默认跳过二进制文件和隐藏文件\n" +
@@ -165,6 +352,35 @@ var rootCmd = &cobra.Command{
 			fmt.Printf("Git提交: %s\n", GitCommit)
 			return
 		}
+		if err := validateSymlinkPolicy(symlinkPolicy); err != nil {
+			printError("%v", err)
+			os.Exit(1)
+		}
+		if err := validateSQLDialect(sqlDialect); err != nil {
+			printError("%v", err)
+			os.Exit(1)
+		}
+		selector, err := gitSelector()
+		if err != nil {
+			printError("%v", err)
+			os.Exit(1)
+		}
+		if selector != "" && targetFile != "" {
+			printError("Git 选择模式不能与 -f 同时使用")
+			os.Exit(1)
+		}
+		if watchMode && (selector != "" || targetFile != "") {
+			printError("--watch 只能用于目录模式")
+			os.Exit(1)
+		}
+		if copyAllFiles && outputDir == "" {
+			printError("--copy-all 需要与 --out 一起使用")
+			os.Exit(1)
+		}
+		if outputDir != "" && resumeMode {
+			printError("--resume 不能与 --out 同时使用")
+			os.Exit(1)
+		}
 		if targetFile != "" {
 			// 处理单个文件
 			if !isSupportedFile(targetFile, forceMode) && !forceMode {
@@ -175,10 +391,35 @@ var rootCmd = &cobra.Command{
 			
 			// 获取文件所在目录作为工作目录
 			fileDir := filepath.Dir(targetFile)
-			if err := processFile(targetFile, fileDir); err != nil {
+			if outputDir != "" {
+				if outputDir, err = prepareOutputDir(outputDir, fileDir); err != nil {
+					printError("%v", err)
+					os.Exit(1)
+				}
+			}
+			filePath, err := resolveTargetFile(targetFile, fileDir)
+			if err != nil {
+				printError("%v", err)
+				os.Exit(1)
+			}
+			if filePath == "" {
+				printSummary()
+				return
+			}
+			// 单个文件的其他硬链接名不会被重新链接，替换后给出提示
+			tracker, err := newLinkTracker(fileDir)
+			if err != nil {
+				printError("%v", err)
+				os.Exit(1)
+			}
+			tracker.markFile(filePath)
+			if err := processFile(filePath, fileDir); err != nil {
+				finishJournal(JournalInterrupted)
 				printError("处理文件失败: %v", err)
 				os.Exit(1)
 			}
+			tracker.warnDetachedHardlinks()
+			finishJournal(JournalFinish)
 			
 			printSummary()
 		} else {
@@ -202,21 +443,240 @@ var rootCmd = &cobra.Command{
 				}
 			}
 			
-			fmt.Printf(ColorBold+ColorPurple+"扫描目录: %s\n"+ColorReset, targetDir)
-			if err := processDirectory(targetDir); err != nil {
+			if outputDir != "" {
+				if outputDir, err = prepareOutputDir(outputDir, targetDir); err != nil {
+					printError("%v", err)
+					os.Exit(1)
+				}
+			}
+			if resumeMode {
+				if err := prepareResume(targetDir); err != nil {
+					printError("%v", err)
+					os.Exit(1)
+				}
+			}
+			
+			setupInterruptHandler()
+			if selector != "" {
+				fmt.Printf(ColorBold+ColorPurple+"Git 模式 (%s): %s\n"+ColorReset, selector, targetDir)
+				err = processGitFiles(targetDir, selector, gitChangedBase)
+			} else {
+				fmt.Printf(ColorBold+ColorPurple+"扫描目录: %s\n"+ColorReset, targetDir)
+				err = processDirectory(targetDir)
+			}
+			if err != nil {
+				if errors.Is(err, errInterrupted) {
+					finishJournal(JournalInterrupted)
+					printSummary()
+					fmt.Println()
+					printWarning("运行已中断，使用 --resume 继续，或使用 restore 子命令回滚本次修改")
+					os.Exit(130)
+				}
+				finishJournal(JournalInterrupted)
 				printError("处理目录失败: %v", err)
 				os.Exit(1)
 			}
 			
+			// 监听模式：首次处理完成后持续处理发生变化的文件
+			if watchMode {
+				if err := watchDirectory(targetDir, watchPoll); err != nil {
+					finishJournal(JournalInterrupted)
+					printError("监听失败: %v", err)
+					os.Exit(1)
+				}
+			}
+			finishJournal(JournalFinish)
+			
 			// 显示处理结果摘要
 			printSummary()
 		}
 	},
 }
 
+var restoreCmd = &cobra.Command{
+	Use:   "restore [snapshot]",
+	Short: "根据运行日志从备份快照恢复文件",
+	Long: "根据备份快照中的运行日志，把该次运行备份过的文件恢复到原位置。\n" +
+		"未指定快照时使用当前目录 bak/ 下最近的快照，可用于回滚被中断的运行。",
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		snapshot, err := resolveSnapshot(args)
+		if err != nil {
+			printError("%v", err)
+			os.Exit(1)
+		}
+		entries, err := loadJournal(snapshot)
+		if err != nil {
+			printError("%v", err)
+			os.Exit(1)
+		}
+		
+		fmt.Printf(ColorBold+ColorPurple+"恢复快照: %s（%s）\n"+ColorReset, snapshot, describeSnapshotStatus(entries))
+		if err := restoreSnapshot(snapshot); err != nil {
+			printError("恢复失败: %v", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var filterCmd = &cobra.Command{
+	Use:   "filter [clean|smudge] [path]",
+	Short: "作为 git clean/smudge 过滤器运行",
+	Long: "不带参数时实现 git 的 long-running filter process 协议（pkt-line，stdin/stdout），\n" +
+		"clean 时根据路径名检测语言并删除注释，smudge 时原样输出。\n\n" +
+		"配置示例:\n" +
+		"  git config filter.nocomment.process \"fuck-comment filter\"\n" +
+		"  echo '*.go filter=nocomment' >> .gitattributes\n\n" +
+		"也可以单次调用：fuck-comment filter clean %f",
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		if len(args) == 0 {
+			err = runFilterProcess(os.Stdin, os.Stdout)
+		} else {
+			pathname := ""
+			if len(args) > 1 {
+				pathname = args[1]
+			}
+			err = runSingleFilter(args[0], pathname, os.Stdin, os.Stdout)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fuck-comment filter: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive <input> <output>",
+	Short: "删除归档文件（tar、tar.gz、zip）中的注释",
+	Long: "读取 .tar、.tar.gz/.tgz 或 .zip 归档，删除其中源码文件的注释并写出新的归档。\n" +
+		"其他条目原样复制，条目的权限、修改时间和符号链接保持不变。\n" +
+		"输出格式由输出文件扩展名决定，支持 tar 与 tar.gz 互转，不支持 tar 与 zip 互转。",
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		printHeader("处理归档: %s -> %s", args[0], args[1])
+		if err := processArchive(args[0], args[1]); err != nil {
+			printError("处理归档失败: %v", err)
+			os.Exit(1)
+		}
+		printSummary()
+		fmt.Printf("\n")
+	},
+}
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "管理和运行 pre-commit hook",
+}
+
+var hookInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "安装 .git/hooks/pre-commit",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := validateHookMode(hookMode); err != nil {
+			printError("%v", err)
+			os.Exit(1)
+		}
+		hookPath, err := installPreCommitHook(".", hookMode, hookOverwrite)
+		if err != nil {
+			printError("%v", err)
+			os.Exit(1)
+		}
+		printSuccess("已安装 pre-commit hook（%s 模式）: %s", hookMode, hookPath)
+	},
+}
+
+var hookUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "删除由 fuck-comment 安装的 pre-commit hook",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		hookPath, err := uninstallPreCommitHook(".")
+		if err != nil {
+			printError("%v", err)
+			os.Exit(1)
+		}
+		printSuccess("已删除 pre-commit hook: %s", hookPath)
+	},
+}
+
+var hookRunCmd = &cobra.Command{
+	Use:   "run [files...]",
+	Short: "检查或删除暂存文件中的注释",
+	Long: "未指定文件时处理暂存区中的文件：check 模式发现注释时阻止提交，\n" +
+		"strip 模式删除注释并重新暂存。指定文件时（pre-commit 框架）直接处理这些文件。",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := validateHookMode(hookMode); err != nil {
+			printError("%v", err)
+			os.Exit(1)
+		}
+		repoRoot, err := gitRepoRoot(".")
+		if err != nil {
+			printError("%v", err)
+			os.Exit(1)
+		}
+		noBackup = true
+		blocked, err := runHook(repoRoot, hookMode, args)
+		if err != nil {
+			printError("%v", err)
+			os.Exit(1)
+		}
+		if blocked {
+			os.Exit(1)
+		}
+	},
+}
+
+var hookYAMLCmd = &cobra.Command{
+	Use:   "pre-commit-yaml",
+	Short: "生成 pre-commit 框架使用的 .pre-commit-hooks.yaml",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		repoRoot, err := gitRepoRoot(".")
+		if err != nil {
+			printError("%v", err)
+			os.Exit(1)
+		}
+		path, err := writePreCommitHooksYAML(repoRoot)
+		if err != nil {
+			printError("写入失败: %v", err)
+			os.Exit(1)
+		}
+		printSuccess("已生成 %s", path)
+	},
+}
+
 func init() {
+	rootCmd.Args = cobra.MaximumNArgs(1)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(filterCmd)
+	rootCmd.AddCommand(archiveCmd)
+	rootCmd.AddCommand(hookCmd)
+	hookCmd.AddCommand(hookInstallCmd, hookUninstallCmd, hookRunCmd, hookYAMLCmd)
+	hookInstallCmd.Flags().StringVar(&hookMode, "mode", HookModeStrip, "hook 模式: check|strip")
+	hookInstallCmd.Flags().BoolVar(&hookOverwrite, "overwrite", false, "覆盖已存在的其他 pre-commit hook")
+	hookRunCmd.Flags().StringVar(&hookMode, "mode", HookModeStrip, "hook 模式: check|strip")
+	archiveCmd.Flags().BoolVar(&forceMode, "force", false, "强制处理所有文件类型（包括二进制文件）")
+	
 	rootCmd.Flags().StringVarP(&targetFile, "file", "f", "", "指定要处理的单个文件")
 	rootCmd.Flags().BoolVar(&forceMode, "force", false, "强制处理所有文件类型（包括二进制文件）")
+	rootCmd.Flags().BoolVar(&preserveMtime, "preserve-mtime", false, "保留文件修改时间（同时应用于输出和备份）")
+	rootCmd.Flags().StringVar(&symlinkPolicy, "symlinks", SymlinkSkip, "符号链接策略: skip|follow|error")
+	rootCmd.Flags().BoolVar(&resumeMode, "resume", false, "继续最近一次被中断的运行")
+	rootCmd.Flags().BoolVar(&gitStaged, "git-staged", false, "只处理Git暂存区中的文件")
+	rootCmd.Flags().StringVar(&gitChangedBase, "git-changed", "", "只处理相对指定提交（base-ref）有变化的文件")
+	rootCmd.Flags().BoolVar(&gitTracked, "git-tracked", false, "只处理Git已跟踪的文件，跳过未跟踪文件")
+	rootCmd.Flags().StringVar(&outputDir, "out", "", "把处理结果写入镜像输出目录，源目录保持不变")
+	rootCmd.Flags().BoolVar(&copyAllFiles, "copy-all", false, "镜像输出时原样复制不支持和跳过的文件")
+	rootCmd.Flags().BoolVar(&clearNotebookOutputs, "nb-clear-outputs", false, "清空 Jupyter notebook 代码单元格的输出和执行计数")
+	rootCmd.Flags().BoolVar(&clearNotebookMarkdown, "nb-clear-markdown", false, "删除 Jupyter notebook 的 markdown 单元格")
+	rootCmd.Flags().StringVar(&sqlDialect, "sql-dialect", SQLDialectAuto, "SQL 方言: auto|ansi|mysql|postgres|oracle")
+	rootCmd.Flags().BoolVar(&keepPerlPOD, "keep-pod", false, "保留 Perl 的 POD 文档（=pod、=head1 ... =cut）")
+	rootCmd.Flags().BoolVar(&keepPowerShellHelp, "keep-ps-help", false, "保留 PowerShell 基于注释的帮助块（含 .SYNOPSIS 等关键字的 <# #>）")
+	rootCmd.Flags().BoolVar(&watchMode, "watch", false, "处理完成后持续监听目录，文件写入后重新删除注释")
+	rootCmd.Flags().BoolVar(&watchPoll, "watch-poll", false, "监听时使用轮询（适用于不支持 inotify 的文件系统）")
 	rootCmd.Flags().BoolVar(&showVersion, "version", false, "显示版本信息")
 }
 
@@ -1,9 +1,17 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -1727,7 +1735,7 @@ func TestLanguageDetection(t *testing.T) {
 		{"test.sh", "sh", "Shell文件检测"},
 		{"test.sql", "sql", "SQL文件检测"},
 		{"test.css", "css", "CSS文件检测"},
-		{"test.html", "xml", "HTML文件检测"},
+		{"test.html", "html", "HTML文件检测"},
 		{"test.xml", "xml", "XML文件检测"},
 		{"test.yaml", "yaml", "YAML文件检测"},
 		{"test.json", "json", "JSON文件检测"},
@@ -1797,7 +1805,7 @@ func TestComprehensiveEdgeCases(t *testing.T) {
 		// 多行注释测试
 		{"c", "int x; /* multi\nline */ int y;", "int x; \n int y;", "C语言多行注释"},
 		{"css", "body { /* multi\nline */ color: red; }", "body { \n color: red; }", "CSS多行注释"},
-		{"lua", "x = 1 --[[ multi\nline ]] y = 2", "x = 1\nline ]] y = 2", "Lua多行注释"},
+		{"lua", "x = 1 --[[ multi\nline ]] y = 2", "x = 1 \n y = 2", "Lua多行注释"},
 		{"haskell", "x = 5 {- multi\nline -} y = 6", "x = 5 \n y = 6", "Haskell多行注释"},
 		{"matlab", "x = 5; %{ multi\nline %} y = 6;", "x = 5;\nline", "MATLAB多行注释"},
 		
@@ -1813,7 +1821,7 @@ func TestComprehensiveEdgeCases(t *testing.T) {
 		{"c", "int x = 5; // comment\nint y = 6;", "int x = 5;\nint y = 6;", "C语言行尾注释"},
 		
 		// 复杂嵌套测试
-		{"javascript", "var s = \"/* not comment */\"; /* real comment */ var x = 5;", "var s = \"/* not comment */\"; /* real comment */ var x = 5;", "JavaScript复杂嵌套"},
+		{"javascript", "var s = \"/* not comment */\"; /* real comment */ var x = 5;", "var s = \"/* not comment */\";  var x = 5;", "JavaScript复杂嵌套"},
 		{"python", "url = \"http://example.com#anchor\" # This is a comment", "url = \"http://example.com#anchor\"", "Python URL井号保护"},
 		{"sql", "SELECT 'Price: $5.00' -- This is money, not comment", "SELECT 'Price: $5.00'", "SQL特殊字符保护"},
 		
@@ -1844,3 +1852,1350 @@ func TestComprehensiveEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+// TestRemoveCommentsStream 测试流式处理与内存处理结果一致
+func TestRemoveCommentsStream(t *testing.T) {
+	tests := []struct {
+		fileType string
+		input    string
+	}{
+		{"go", "package main\n// 注释\nfunc main() { /* 块\n注释 */ x := 1 // 行尾\n}\n"},
+		{"sql", "SELECT 1; -- comment\n/* multi\nline */\nSELECT 2;"},
+		{"psql", "CREATE FUNCTION f() AS $$\n-- kept\n$$; -- comment\n/* a /* b */ c */\n"},
+		{"python", "def f():\n    \"\"\"doc # not comment\n    \"\"\"\n    return 1  # comment\n"},
+		{"yaml", "key: value # comment\ntext: |\n  # kept\nother: 1\n"},
+		{"go", ""},
+		{"go", "\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileType, func(t *testing.T) {
+			var out strings.Builder
+			changed, err := removeCommentsStream(strings.NewReader(tt.input), &out, tt.fileType, maxLineLength)
+			if err != nil {
+				t.Fatalf("removeCommentsStream() error = %v", err)
+			}
+			expected := removeComments(tt.input, tt.fileType)
+			assertStringEqual(t, expected, out.String(), "流式处理"+tt.fileType)
+			if changed != (expected != tt.input) {
+				t.Errorf("changed = %v, 期望 %v", changed, expected != tt.input)
+			}
+		})
+	}
+
+	t.Run("超长行", func(t *testing.T) {
+		input := strings.Repeat("a", 200) + "\nb"
+		_, err := removeCommentsStream(strings.NewReader(input), ioutil.Discard, "go", 100)
+		if err == nil {
+			t.Error("超长行应该返回错误")
+		}
+	})
+}
+
+// TestProcessFileStream 测试大文件通过流式路径处理
+func TestProcessFileStream(t *testing.T) {
+	resetBackupGlobals()
+	oldThreshold := streamThreshold
+	streamThreshold = 16
+	defer func() {
+		streamThreshold = oldThreshold
+		resetBackupGlobals()
+	}()
+
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "dump.sql")
+	content := "-- header\nSELECT 1; -- trailing\nSELECT 2;\n"
+	if err := os.WriteFile(testFile, []byte(content), 0755); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	if err := processFile(testFile, tempDir); err != nil {
+		t.Fatalf("处理文件失败: %v", err)
+	}
+
+	result, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("读取处理后文件失败: %v", err)
+	}
+	assertStringEqual(t, "SELECT 1;\nSELECT 2;\n", string(result), "流式处理文件")
+
+	info, err := os.Stat(testFile)
+	if err != nil {
+		t.Fatalf("读取文件信息失败: %v", err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("文件权限 = %v, 期望 0755", info.Mode().Perm())
+	}
+
+	backup, err := os.ReadFile(filepath.Join(backupRootDir, "dump.sql"))
+	if err != nil {
+		t.Fatalf("读取备份失败: %v", err)
+	}
+	assertStringEqual(t, content, string(backup), "流式处理备份")
+}
+
+// TestAtomicWritePreservesMetadata 测试原子写入保留权限和修改时间
+func TestAtomicWritePreservesMetadata(t *testing.T) {
+	resetBackupGlobals()
+	preserveMtime = true
+	defer func() {
+		preserveMtime = false
+		resetBackupGlobals()
+	}()
+
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "run.sh")
+	if err := os.WriteFile(testFile, []byte("#!/bin/sh\n# comment\necho hi\n"), 0755); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	if err := os.Chmod(testFile, 0750); err != nil {
+		t.Fatalf("设置权限失败: %v", err)
+	}
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(testFile, mtime, mtime); err != nil {
+		t.Fatalf("设置修改时间失败: %v", err)
+	}
+
+	if err := processFile(testFile, tempDir); err != nil {
+		t.Fatalf("处理文件失败: %v", err)
+	}
+
+	for _, path := range []string{testFile, filepath.Join(backupRootDir, "run.sh")} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("读取文件信息失败: %v", err)
+		}
+		if info.Mode().Perm() != 0750 {
+			t.Errorf("%s 权限 = %v, 期望 0750", path, info.Mode().Perm())
+		}
+		if !info.ModTime().Equal(mtime) {
+			t.Errorf("%s 修改时间 = %v, 期望 %v", path, info.ModTime(), mtime)
+		}
+	}
+
+	// 不应残留临时文件
+	entries, err := os.ReadDir(tempDir)
+	if err != nil {
+		t.Fatalf("读取目录失败: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".tmp") {
+			t.Errorf("残留临时文件: %s", entry.Name())
+		}
+	}
+}
+
+// TestSymlinkAndHardlinkPolicy 测试符号链接策略、循环检测和硬链接去重
+func TestSymlinkAndHardlinkPolicy(t *testing.T) {
+	setup := func(t *testing.T) (string, string) {
+		resetBackupGlobals()
+		processedFiles = nil
+		skippedFiles = nil
+		base := t.TempDir()
+		root := filepath.Join(base, "project")
+		outside := filepath.Join(base, "outside")
+		for _, dir := range []string{root, outside, filepath.Join(root, "sub")} {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatalf("创建目录失败: %v", err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(root, "sub", "a.go"), []byte("x := 1 // a\n"), 0644); err != nil {
+			t.Fatalf("创建文件失败: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(outside, "b.go"), []byte("y := 2 // b\n"), 0644); err != nil {
+			t.Fatalf("创建文件失败: %v", err)
+		}
+		return root, outside
+	}
+	defer func() {
+		symlinkPolicy = SymlinkSkip
+		resetBackupGlobals()
+	}()
+
+	t.Run("默认跳过符号链接", func(t *testing.T) {
+		root, outside := setup(t)
+		symlinkPolicy = SymlinkSkip
+		link := filepath.Join(root, "b.go")
+		if err := os.Symlink(filepath.Join(outside, "b.go"), link); err != nil {
+			t.Skipf("不支持符号链接: %v", err)
+		}
+		if err := processDirectory(root); err != nil {
+			t.Fatalf("处理目录失败: %v", err)
+		}
+		content, _ := os.ReadFile(filepath.Join(outside, "b.go"))
+		assertStringEqual(t, "y := 2 // b\n", string(content), "根目录外的文件不应被修改")
+		if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+			t.Error("符号链接不应被替换为普通文件")
+		}
+	})
+
+	t.Run("跟随模式拒绝根目录外目标并检测循环", func(t *testing.T) {
+		root, outside := setup(t)
+		symlinkPolicy = SymlinkFollow
+		if err := os.Symlink(filepath.Join(outside, "b.go"), filepath.Join(root, "b.go")); err != nil {
+			t.Skipf("不支持符号链接: %v", err)
+		}
+		if err := os.Symlink(root, filepath.Join(root, "sub", "loop")); err != nil {
+			t.Fatalf("创建符号链接失败: %v", err)
+		}
+		if err := os.Symlink(filepath.Join(root, "sub", "a.go"), filepath.Join(root, "alias.go")); err != nil {
+			t.Fatalf("创建符号链接失败: %v", err)
+		}
+		if err := processDirectory(root); err != nil {
+			t.Fatalf("处理目录失败: %v", err)
+		}
+		content, _ := os.ReadFile(filepath.Join(outside, "b.go"))
+		assertStringEqual(t, "y := 2 // b\n", string(content), "根目录外的文件不应被修改")
+		content, _ = os.ReadFile(filepath.Join(root, "sub", "a.go"))
+		assertStringEqual(t, "x := 1\n", string(content), "链接目标应被处理")
+		if len(processedFiles) != 1 {
+			t.Errorf("同一文件应只处理一次, 实际处理 %d 次", len(processedFiles))
+		}
+	})
+
+	t.Run("目标之后遍历到的符号链接", func(t *testing.T) {
+		root, _ := setup(t)
+		symlinkPolicy = SymlinkFollow
+		// 按字典序 sub/a.go 先于 z.go 被遍历，处理后 a.go 已被原子替换为新的 inode
+		if err := os.Symlink(filepath.Join(root, "sub", "a.go"), filepath.Join(root, "z.go")); err != nil {
+			t.Skipf("不支持符号链接: %v", err)
+		}
+		tracker, err := newLinkTracker(root)
+		if err != nil {
+			t.Fatalf("创建链接跟踪器失败: %v", err)
+		}
+		if err := walkDirectory(tracker, tracker.root); err != nil {
+			t.Fatalf("处理目录失败: %v", err)
+		}
+		content, _ := os.ReadFile(filepath.Join(root, "sub", "a.go"))
+		assertStringEqual(t, "x := 1\n", string(content), "链接目标应被处理")
+		if len(processedFiles) != 1 {
+			t.Errorf("同一文件应只处理一次, 实际处理 %d 次", len(processedFiles))
+		}
+		if len(tracker.seenFiles) != 1 {
+			t.Errorf("原子替换后的文件应被识别为已处理, 实际登记 %d 个物理文件", len(tracker.seenFiles))
+		}
+	})
+
+	t.Run("错误模式", func(t *testing.T) {
+		root, outside := setup(t)
+		symlinkPolicy = SymlinkError
+		if err := os.Symlink(filepath.Join(outside, "b.go"), filepath.Join(root, "b.go")); err != nil {
+			t.Skipf("不支持符号链接: %v", err)
+		}
+		if err := processDirectory(root); err == nil {
+			t.Error("遇到符号链接时应返回错误")
+		}
+	})
+
+	t.Run("硬链接去重", func(t *testing.T) {
+		root, _ := setup(t)
+		symlinkPolicy = SymlinkSkip
+		original := filepath.Join(root, "sub", "a.go")
+		hardlink := filepath.Join(root, "z.go")
+		if err := os.Link(original, hardlink); err != nil {
+			t.Skipf("不支持硬链接: %v", err)
+		}
+		before, err := os.Stat(original)
+		if err != nil {
+			t.Fatalf("读取文件信息失败: %v", err)
+		}
+		if err := processDirectory(root); err != nil {
+			t.Fatalf("处理目录失败: %v", err)
+		}
+		if len(processedFiles) != 1 {
+			t.Errorf("硬链接文件应只处理一次, 实际处理 %d 次", len(processedFiles))
+		}
+		content, _ := os.ReadFile(hardlink)
+		assertStringEqual(t, "x := 1\n", string(content), "硬链接应保持指向同一文件")
+		originalInfo, _ := os.Stat(original)
+		hardlinkInfo, _ := os.Stat(hardlink)
+		if !os.SameFile(originalInfo, hardlinkInfo) {
+			t.Error("处理后两个链接名应指向同一文件")
+		}
+		if os.SameFile(before, originalInfo) {
+			t.Error("文件应通过 rename 原子替换，而不是原地覆盖")
+		}
+	})
+
+	t.Run("根目录外的硬链接保持原内容", func(t *testing.T) {
+		root, outside := setup(t)
+		symlinkPolicy = SymlinkSkip
+		external := filepath.Join(outside, "a.go")
+		if err := os.Link(filepath.Join(root, "sub", "a.go"), external); err != nil {
+			t.Skipf("不支持硬链接: %v", err)
+		}
+		if err := processDirectory(root); err != nil {
+			t.Fatalf("处理目录失败: %v", err)
+		}
+		content, _ := os.ReadFile(filepath.Join(root, "sub", "a.go"))
+		assertStringEqual(t, "x := 1\n", string(content), "根目录内的文件应被处理")
+		content, _ = os.ReadFile(external)
+		assertStringEqual(t, "x := 1 // a\n", string(content), "根目录外的链接名不应被修改")
+	})
+}
+
+// TestRunJournalResumeAndRestore 测试运行日志的中断、续传和回滚
+func TestRunJournalResumeAndRestore(t *testing.T) {
+	resetBackupGlobals()
+	processedFiles = nil
+	defer func() {
+		resetBackupGlobals()
+		resumedFiles = nil
+		interrupted.Store(false)
+	}()
+
+	root, err := resolveRealPath(t.TempDir())
+	if err != nil {
+		t.Fatalf("解析目录失败: %v", err)
+	}
+	files := map[string]string{
+		"a.go": "a := 1 // a\n",
+		"b.go": "b := 2 // b\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatalf("创建文件失败: %v", err)
+		}
+	}
+
+	t.Run("中断后不再处理新文件", func(t *testing.T) {
+		interrupted.Store(true)
+		defer interrupted.Store(false)
+		if err := processDirectory(root); err != errInterrupted {
+			t.Errorf("processDirectory() error = %v, 期望 errInterrupted", err)
+		}
+	})
+
+	t.Run("续传跳过已完成文件", func(t *testing.T) {
+		// 模拟一次只完成了 a.go 的中断运行
+		backupTimestamp = "20200101_000000"
+		if err := processFile(filepath.Join(root, "a.go"), root); err != nil {
+			t.Fatalf("处理文件失败: %v", err)
+		}
+		finishJournal(JournalInterrupted)
+		snapshot := backupRootDir
+
+		resetBackupGlobals()
+		if err := prepareResume(root); err != nil {
+			t.Fatalf("prepareResume() error = %v", err)
+		}
+		if backupRootDir != snapshot {
+			t.Fatalf("续传应复用快照 %s, 实际 %s", snapshot, backupRootDir)
+		}
+		if !isResumedFile(filepath.Join(root, "a.go"), root) {
+			t.Error("a.go 应被标记为已完成")
+		}
+		if err := processDirectory(root); err != nil {
+			t.Fatalf("处理目录失败: %v", err)
+		}
+		finishJournal(JournalFinish)
+
+		entries, err := loadJournal(snapshot)
+		if err != nil {
+			t.Fatalf("读取运行日志失败: %v", err)
+		}
+		if !isJournalComplete(entries) {
+			t.Error("续传完成后运行日志应记录结束")
+		}
+		if err := prepareResume(root); err == nil {
+			t.Error("已完成的运行不应再被续传")
+		}
+	})
+
+	t.Run("按运行日志回滚", func(t *testing.T) {
+		if err := restoreSnapshot(backupRootDir); err != nil {
+			t.Fatalf("restoreSnapshot() error = %v", err)
+		}
+		for name, content := range files {
+			result, _ := os.ReadFile(filepath.Join(root, name))
+			assertStringEqual(t, content, string(result), "回滚"+name)
+		}
+	})
+}
+
+// TestGitFileSelection 测试 Git 暂存、变更和跟踪文件选择
+func TestGitFileSelection(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("未安装 git")
+	}
+	root, err := resolveRealPath(t.TempDir())
+	if err != nil {
+		t.Fatalf("解析目录失败: %v", err)
+	}
+	git := func(args ...string) {
+		t.Helper()
+		base := []string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}
+		if out, err := exec.Command("git", append(base, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v 失败: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatalf("创建文件失败: %v", err)
+		}
+	}
+
+	git("init", "-q")
+	write("committed.go", "a := 1 // a\n")
+	git("add", "committed.go")
+	git("commit", "-q", "-m", "init")
+	write("staged.go", "b := 2 // b\n")
+	git("add", "staged.go")
+	write("untracked.go", "c := 3 // c\n")
+
+	tests := []struct {
+		selector string
+		baseRef  string
+		expected []string
+	}{
+		{GitSelectStaged, "", []string{"staged.go"}},
+		{GitSelectChanged, "HEAD", []string{"staged.go"}},
+		{GitSelectTracked, "", []string{"committed.go", "staged.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			files, err := listGitFiles(root, tt.selector, tt.baseRef)
+			if err != nil {
+				t.Fatalf("listGitFiles() error = %v", err)
+			}
+			if strings.Join(files, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("listGitFiles() = %v, 期望 %v", files, tt.expected)
+			}
+		})
+	}
+
+	t.Run("拒绝选项形式的基准提交", func(t *testing.T) {
+		if _, err := listGitFiles(root, GitSelectChanged, "--output=x"); err == nil {
+			t.Error("以 - 开头的基准提交应返回错误")
+		}
+		if _, err := os.Stat(filepath.Join(root, "x")); err == nil {
+			t.Error("基准提交不应作为 git 选项执行")
+		}
+	})
+
+	t.Run("只处理暂存文件", func(t *testing.T) {
+		resetBackupGlobals()
+		defer resetBackupGlobals()
+		if err := processGitFiles(root, GitSelectStaged, ""); err != nil {
+			t.Fatalf("processGitFiles() error = %v", err)
+		}
+		for name, expected := range map[string]string{
+			"committed.go": "a := 1 // a\n",
+			"staged.go":    "b := 2\n",
+			"untracked.go": "c := 3 // c\n",
+		} {
+			content, _ := os.ReadFile(filepath.Join(root, name))
+			assertStringEqual(t, expected, string(content), name)
+		}
+	})
+}
+
+// TestGitFilterProcess 测试 git long-running filter 协议
+func TestGitFilterProcess(t *testing.T) {
+	pkt := func(text string) string {
+		return fmt.Sprintf("%04x%s", len(text)+4, text)
+	}
+	content := "package main\n// comment\nfunc main() {} // trailing\n"
+
+	var input strings.Builder
+	input.WriteString(pkt("git-filter-client\n") + pkt("version=2\n") + "0000")
+	input.WriteString(pkt("capability=clean\n") + pkt("capability=smudge\n") + pkt("capability=delay\n") + "0000")
+	input.WriteString(pkt("command=clean\n") + pkt("pathname=src/main.go\n") + "0000")
+	input.WriteString(pkt(content) + "0000")
+	input.WriteString(pkt("command=smudge\n") + pkt("pathname=src/main.go\n") + "0000")
+	input.WriteString(pkt(content) + "0000")
+
+	var output bytes.Buffer
+	if err := runFilterProcess(strings.NewReader(input.String()), &output); err != nil {
+		t.Fatalf("runFilterProcess() error = %v", err)
+	}
+
+	r := bufio.NewReader(&output)
+	expectList := func(expected ...string) {
+		t.Helper()
+		list, err := readPktTextList(r)
+		if err != nil {
+			t.Fatalf("读取响应失败: %v", err)
+		}
+		if strings.Join(list, ",") != strings.Join(expected, ",") {
+			t.Errorf("响应 = %v, 期望 %v", list, expected)
+		}
+	}
+
+	expectList("git-filter-server", "version=2")
+	expectList("capability=clean", "capability=smudge")
+
+	expectList("status=success")
+	cleaned, err := readPktContent(r)
+	if err != nil {
+		t.Fatalf("读取内容失败: %v", err)
+	}
+	assertStringEqual(t, "package main\nfunc main() {}\n", string(cleaned), "clean")
+	expectList()
+
+	expectList("status=success")
+	smudged, err := readPktContent(r)
+	if err != nil {
+		t.Fatalf("读取内容失败: %v", err)
+	}
+	assertStringEqual(t, content, string(smudged), "smudge")
+	expectList()
+}
+
+// TestGitFilterAmbiguousContent 测试过滤器根据协议传入的内容（而不是磁盘上的文件）检测歧义扩展名
+func TestGitFilterAmbiguousContent(t *testing.T) {
+	matlab := "function y = f(x)\n  y = x; % comment\nend\n"
+	assertStringEqual(t, "function y = f(x)\n  y = x;\nend\n", string(filterContent("clean", "lib/not-on-disk.m", []byte(matlab))), "MATLAB内容")
+
+	r := "library(dplyr)\nx <- 1 # comment\n"
+	assertStringEqual(t, "library(dplyr)\nx <- 1\n", string(filterContent("clean", "lib/not-on-disk.r", []byte(r))), "R内容")
+}
+
+// TestPktLineLargeContent 测试大内容被拆分为多个数据包
+func TestPktLineLargeContent(t *testing.T) {
+	content := bytes.Repeat([]byte("x"), pktMaxPayload*2+10)
+	var buf bytes.Buffer
+	if err := writePktContent(&buf, content); err != nil {
+		t.Fatalf("writePktContent() error = %v", err)
+	}
+	result, err := readPktContent(&buf)
+	if err != nil {
+		t.Fatalf("readPktContent() error = %v", err)
+	}
+	if !bytes.Equal(content, result) {
+		t.Error("拆包后的内容与原内容不一致")
+	}
+}
+
+// TestPreCommitHook 测试 hook 安装、卸载以及检查和删除模式
+func TestPreCommitHook(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("未安装 git")
+	}
+	root, err := resolveRealPath(t.TempDir())
+	if err != nil {
+		t.Fatalf("解析目录失败: %v", err)
+	}
+	git := func(args ...string) {
+		t.Helper()
+		if out, err := exec.Command("git", append([]string{"-C", root}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v 失败: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+
+	t.Run("安装和卸载", func(t *testing.T) {
+		hookPath, err := installPreCommitHook(root, HookModeCheck, false)
+		if err != nil {
+			t.Fatalf("installPreCommitHook() error = %v", err)
+		}
+		content, _ := os.ReadFile(hookPath)
+		if !strings.Contains(string(content), "hook run --mode check") {
+			t.Errorf("hook 脚本内容不正确: %s", content)
+		}
+		if _, err := uninstallPreCommitHook(root); err != nil {
+			t.Fatalf("uninstallPreCommitHook() error = %v", err)
+		}
+
+		// 不覆盖其他工具的 hook
+		if err := os.WriteFile(hookPath, []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+			t.Fatalf("写入 hook 失败: %v", err)
+		}
+		if _, err := installPreCommitHook(root, HookModeStrip, false); err == nil {
+			t.Error("存在其他 hook 时应返回错误")
+		} else if !strings.Contains(err.Error(), "--overwrite") || hookInstallCmd.Flags().Lookup("overwrite") == nil {
+			t.Errorf("错误提示应指向 hook install 的 --overwrite 参数: %v", err)
+		}
+		if _, err := uninstallPreCommitHook(root); err == nil {
+			t.Error("不应删除其他工具的 hook")
+		}
+	})
+
+	noBackup = true
+	defer func() { noBackup = false }()
+	file := filepath.Join(root, "main.go")
+	if err := os.WriteFile(file, []byte("x := 1 // comment\n"), 0644); err != nil {
+		t.Fatalf("创建文件失败: %v", err)
+	}
+	git("add", "main.go")
+
+	t.Run("检查模式", func(t *testing.T) {
+		blocked, err := runHook(root, HookModeCheck, nil)
+		if err != nil {
+			t.Fatalf("runHook() error = %v", err)
+		}
+		if !blocked {
+			t.Error("包含注释时应阻止提交")
+		}
+	})
+
+	t.Run("删除模式并重新暂存", func(t *testing.T) {
+		blocked, err := runHook(root, HookModeStrip, nil)
+		if err != nil {
+			t.Fatalf("runHook() error = %v", err)
+		}
+		if blocked {
+			t.Error("删除模式不应阻止提交")
+		}
+		staged, err := runGit(root, "show", ":main.go")
+		if err != nil {
+			t.Fatalf("读取暂存内容失败: %v", err)
+		}
+		assertStringEqual(t, "x := 1\n", string(staged), "暂存内容")
+	})
+
+	t.Run("按暂存内容检测歧义扩展名", func(t *testing.T) {
+		// 暂存的是 MATLAB，工作区中已改成没有 MATLAB 特征的内容
+		script := filepath.Join(root, "calc.m")
+		if err := os.WriteFile(script, []byte("function y = f(x)\n  y = x; % comment\nend\n"), 0644); err != nil {
+			t.Fatalf("创建文件失败: %v", err)
+		}
+		git("add", "calc.m")
+		if err := os.WriteFile(script, []byte("y = 1;\n"), 0644); err != nil {
+			t.Fatalf("修改文件失败: %v", err)
+		}
+		blocked, err := runHook(root, HookModeCheck, nil)
+		if err != nil {
+			t.Fatalf("runHook() error = %v", err)
+		}
+		if !blocked {
+			t.Error("暂存的 MATLAB 内容包含注释时应阻止提交")
+		}
+	})
+}
+
+// TestPreCommitHooksYAMLInSync 测试仓库中的 .pre-commit-hooks.yaml 与生成内容一致
+func TestPreCommitHooksYAMLInSync(t *testing.T) {
+	content, err := os.ReadFile(".pre-commit-hooks.yaml")
+	if err != nil {
+		t.Fatalf("读取 .pre-commit-hooks.yaml 失败: %v", err)
+	}
+	assertStringEqual(t, preCommitHooksYAML, string(content), ".pre-commit-hooks.yaml")
+}
+
+// TestWatchDirectory 测试监听模式在文件写入后重新处理，且不响应自身写入和备份
+func TestWatchDirectory(t *testing.T) {
+	resetBackupGlobals()
+	defer func() {
+		resetBackupGlobals()
+		interrupted.Store(false)
+	}()
+
+	root, err := resolveRealPath(t.TempDir())
+	if err != nil {
+		t.Fatalf("解析目录失败: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- watchDirectory(root, false) }()
+	time.Sleep(200 * time.Millisecond)
+
+	file := filepath.Join(root, "main.go")
+	if err := os.WriteFile(file, []byte("x := 1 // comment\n"), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		content, _ := os.ReadFile(file)
+		if string(content) == "x := 1\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("监听模式没有处理文件, 内容: %q", content)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	interrupted.Store(true)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("watchDirectory() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("中断后监听没有停止")
+	}
+}
+
+// TestPollWatcher 测试轮询监听器发现文件变化并忽略备份目录
+func TestPollWatcher(t *testing.T) {
+	root, err := resolveRealPath(t.TempDir())
+	if err != nil {
+		t.Fatalf("解析目录失败: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "bak"), 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+
+	watcher := newPollWatcher(root, 20*time.Millisecond)
+	defer watcher.Close()
+	time.Sleep(50 * time.Millisecond)
+
+	if err := os.WriteFile(filepath.Join(root, "bak", "old.go"), []byte("x"), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	file := filepath.Join(root, "a.go")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+
+	select {
+	case path := <-watcher.Events:
+		if path != file {
+			t.Errorf("事件路径 = %s, 期望 %s", path, file)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("轮询监听器没有发现文件变化")
+	}
+}
+
+// TestMirrorOutput 测试镜像输出目录模式不修改源目录
+func TestMirrorOutput(t *testing.T) {
+	resetBackupGlobals()
+	defer func() {
+		outputDir = ""
+		copyAllFiles = false
+		resetBackupGlobals()
+	}()
+
+	base := t.TempDir()
+	source := filepath.Join(base, "src")
+	files := map[string]string{
+		"main.go":        "x := 1 // comment\n",
+		"pkg/util.py":    "y = 2  # comment\n",
+		"pkg/clean.go":   "z := 3\n",
+		"assets/logo.png": "\x89PNG",
+		".gitignore":      "*.log # comment\n",
+		".github/ci.yml":  "on: push # comment\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(source, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("创建目录失败: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("创建文件失败: %v", err)
+		}
+	}
+	// 硬链接的两个名字在镜像中都应有输出
+	if err := os.Link(filepath.Join(source, "main.go"), filepath.Join(source, "pkg/alias.go")); err != nil {
+		t.Fatalf("创建硬链接失败: %v", err)
+	}
+
+	if _, err := prepareOutputDir(source, source); err == nil {
+		t.Error("输出目录与源目录相同时应返回错误")
+	}
+
+	var err error
+	outputDir, err = prepareOutputDir(filepath.Join(base, "out"), source)
+	if err != nil {
+		t.Fatalf("prepareOutputDir() error = %v", err)
+	}
+	copyAllFiles = true
+	if err := processDirectory(source); err != nil {
+		t.Fatalf("处理目录失败: %v", err)
+	}
+
+	expected := map[string]string{
+		"main.go":         "x := 1\n",
+		"pkg/util.py":     "y = 2\n",
+		"pkg/clean.go":    "z := 3\n",
+		"assets/logo.png": "\x89PNG",
+		"pkg/alias.go":    "x := 1\n",
+		".gitignore":      "*.log # comment\n",
+		".github/ci.yml":  "on: push # comment\n",
+	}
+	for name, content := range expected {
+		result, err := os.ReadFile(filepath.Join(outputDir, name))
+		if err != nil {
+			t.Errorf("输出文件 %s 不存在: %v", name, err)
+			continue
+		}
+		assertStringEqual(t, content, string(result), "输出"+name)
+	}
+	for name, content := range files {
+		result, _ := os.ReadFile(filepath.Join(source, name))
+		assertStringEqual(t, content, string(result), "源文件"+name)
+	}
+	if _, err := os.Stat(filepath.Join(source, "bak")); err == nil {
+		t.Error("镜像输出模式不应创建备份目录")
+	}
+}
+
+// TestArchiveProcessing 测试 tar.gz 和 zip 归档的注释删除与头信息保留
+func TestArchiveProcessing(t *testing.T) {
+	base := t.TempDir()
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	binary := "\x89PNG\x00\x01// not a comment"
+
+	// 构造 tar.gz 输入
+	tarPath := filepath.Join(base, "src.tar.gz")
+	var tarBuf bytes.Buffer
+	gz := gzip.NewWriter(&tarBuf)
+	tw := tar.NewWriter(gz)
+	tarEntries := []struct {
+		hdr     tar.Header
+		content string
+	}{
+		{tar.Header{Typeflag: tar.TypeDir, Name: "src/", Mode: 0755, ModTime: mtime}, ""},
+		{tar.Header{Typeflag: tar.TypeReg, Name: "src/main.go", Mode: 0750, ModTime: mtime}, "x := 1 // comment\n"},
+		{tar.Header{Typeflag: tar.TypeReg, Name: "src/logo.png", Mode: 0644, ModTime: mtime}, binary},
+		{tar.Header{Typeflag: tar.TypeSymlink, Name: "src/link.go", Linkname: "main.go", Mode: 0777, ModTime: mtime}, ""},
+	}
+	for _, entry := range tarEntries {
+		hdr := entry.hdr
+		hdr.Size = int64(len(entry.content))
+		if err := tw.WriteHeader(&hdr); err != nil {
+			t.Fatalf("写入 tar 头失败: %v", err)
+		}
+		tw.Write([]byte(entry.content))
+	}
+	tw.Close()
+	gz.Close()
+	if err := os.WriteFile(tarPath, tarBuf.Bytes(), 0644); err != nil {
+		t.Fatalf("写入归档失败: %v", err)
+	}
+
+	tarOut := filepath.Join(base, "out.tar")
+	if err := processArchive(tarPath, tarOut); err != nil {
+		t.Fatalf("处理 tar.gz 失败: %v", err)
+	}
+	f, err := os.Open(tarOut)
+	if err != nil {
+		t.Fatalf("打开输出失败: %v", err)
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	expected := map[string]string{"src/main.go": "x := 1\n", "src/logo.png": binary}
+	count := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("读取输出 tar 失败: %v", err)
+		}
+		count++
+		if !hdr.ModTime.Equal(mtime) {
+			t.Errorf("%s 修改时间 = %v, 期望 %v", hdr.Name, hdr.ModTime, mtime)
+		}
+		switch hdr.Name {
+		case "src/main.go":
+			if hdr.Mode != 0750 {
+				t.Errorf("main.go 权限 = %o, 期望 0750", hdr.Mode)
+			}
+		case "src/link.go":
+			if hdr.Typeflag != tar.TypeSymlink || hdr.Linkname != "main.go" {
+				t.Errorf("符号链接未保留: %+v", hdr)
+			}
+		}
+		if want, ok := expected[hdr.Name]; ok {
+			content, _ := io.ReadAll(tr)
+			assertStringEqual(t, want, string(content), "tar 条目"+hdr.Name)
+		}
+	}
+	if count != len(tarEntries) {
+		t.Errorf("输出条目数 = %d, 期望 %d", count, len(tarEntries))
+	}
+
+	// 构造 zip 输入
+	zipPath := filepath.Join(base, "src.zip")
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for name, content := range map[string]string{"a.py": "y = 2  # comment\n", "clean.go": "z := 3\n"} {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: mtime}
+		header.SetMode(0640)
+		w, _ := zw.CreateHeader(header)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	if err := os.WriteFile(zipPath, zipBuf.Bytes(), 0644); err != nil {
+		t.Fatalf("写入归档失败: %v", err)
+	}
+
+	if err := processArchive(zipPath, filepath.Join(base, "out.tar")); err == nil {
+		t.Error("zip 转 tar 应返回错误")
+	}
+
+	zipOut := filepath.Join(base, "out.zip")
+	if err := processArchive(zipPath, zipOut); err != nil {
+		t.Fatalf("处理 zip 失败: %v", err)
+	}
+	zr, err := zip.OpenReader(zipOut)
+	if err != nil {
+		t.Fatalf("打开输出 zip 失败: %v", err)
+	}
+	defer zr.Close()
+	expected = map[string]string{"a.py": "y = 2\n", "clean.go": "z := 3\n"}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("读取 zip 条目失败: %v", err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		assertStringEqual(t, expected[f.Name], string(content), "zip 条目"+f.Name)
+		if f.Mode().Perm() != 0640 {
+			t.Errorf("%s 权限 = %o, 期望 0640", f.Name, f.Mode().Perm())
+		}
+		if !f.Modified.Equal(mtime) {
+			t.Errorf("%s 修改时间 = %v, 期望 %v", f.Name, f.Modified, mtime)
+		}
+	}
+}
+
+// TestNotebookComments 测试 Jupyter notebook 代码单元格的注释删除和写出格式
+func TestNotebookComments(t *testing.T) {
+	defer func() {
+		clearNotebookOutputs = false
+		clearNotebookMarkdown = false
+	}()
+
+	notebook := `{
+ "cells": [
+  {
+   "cell_type": "markdown",
+   "metadata": {},
+   "source": ["# 标题\n", "说明 <b>"]
+  },
+  {
+   "cell_type": "code",
+   "execution_count": 3,
+   "id": "a1",
+   "metadata": {},
+   "outputs": [{"name": "stdout", "output_type": "stream", "text": ["1\n"]}],
+   "source": ["x = 1  # comment\n", "# only comment\n", "print(x)"]
+  }
+ ],
+ "metadata": {"kernelspec": {"display_name": "Python 3", "language": "python", "name": "python3"}},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`
+	result := removeComments(notebook, detectFileTypeFromContent("demo.ipynb", []byte(notebook)))
+	var parsed map[string]interface{}
+	if err := json.Unmarshal([]byte(result), &parsed); err != nil {
+		t.Fatalf("输出不是有效的 JSON: %v\n%s", err, result)
+	}
+	cells := parsed["cells"].([]interface{})
+	markdown := cells[0].(map[string]interface{})
+	code := cells[1].(map[string]interface{})
+	assertStringEqual(t, "# 标题\n", markdown["source"].([]interface{})[0].(string), "markdown 单元格")
+	source := code["source"].([]interface{})
+	if len(source) != 2 || source[0] != "x = 1\n" || source[1] != "print(x)" {
+		t.Errorf("代码单元格 source = %q", source)
+	}
+	if len(code["outputs"].([]interface{})) != 1 {
+		t.Error("未指定清空输出时应保留输出")
+	}
+	// 键排序、缩进 1 个空格、不转义 HTML 字符
+	if !strings.HasPrefix(result, "{\n \"cells\": [\n  {\n   \"cell_type\": \"markdown\",\n   \"metadata\": {},") {
+		t.Errorf("输出格式不符合 nbformat:\n%s", result)
+	}
+	if !strings.Contains(result, "说明 <b>") || !strings.HasSuffix(result, "}\n") {
+		t.Errorf("输出格式不符合 nbformat:\n%s", result)
+	}
+
+	// 无修改时原样返回
+	clean := strings.Replace(notebook, `"x = 1  # comment\n", "# only comment\n", `, "", 1)
+	assertStringEqual(t, clean, removeComments(clean, "ipynb"), "无注释 notebook")
+
+	clearNotebookOutputs = true
+	clearNotebookMarkdown = true
+	json.Unmarshal([]byte(removeComments(notebook, "ipynb")), &parsed)
+	cells = parsed["cells"].([]interface{})
+	if len(cells) != 1 {
+		t.Fatalf("markdown 单元格应被删除，剩余 %d 个单元格", len(cells))
+	}
+	code = cells[0].(map[string]interface{})
+	if len(code["outputs"].([]interface{})) != 0 || code["execution_count"] != nil {
+		t.Errorf("输出和执行计数应被清空: %v", code)
+	}
+
+	if err := isFileSafe("bad.ipynb", []byte("{not json"), false); err == nil {
+		t.Error("无效的 notebook 应被安全检查拒绝")
+	}
+}
+
+// TestMarkupRegions 测试 HTML 和单文件组件中按区域删除注释
+func TestMarkupRegions(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		input    string
+		expected string
+	}{
+		{
+			"HTML内嵌脚本和样式",
+			"html",
+			"<!-- 页面 -->\n<style>\n/* 样式 */\n#app { color: red; }\n</style>\n<script>\n// 脚本注释\nconst a = 1; // 行尾\n</script>\n<p># 不是注释</p>\n",
+			"<style>\n#app { color: red; }\n</style>\n<script>\nconst a = 1;\n</script>\n<p># 不是注释</p>\n",
+		},
+		{
+			"Vue组件",
+			"vue",
+			"<template>\n  <div id=\"app\"><!-- 模板注释 -->{{ msg }}</div>\n</template>\n<script setup lang=\"ts\">\n// 注释\nconst msg: string = 'hi' // 行尾\n</script>\n<style lang=\"scss\" scoped>\n/* 样式 */\n#app { color: red; }\n</style>\n",
+			"<template>\n  <div id=\"app\">{{ msg }}</div>\n</template>\n<script setup lang=\"ts\">\nconst msg: string = 'hi'\n</script>\n<style lang=\"scss\" scoped>\n#app { color: red; }\n</style>\n",
+		},
+		{
+			"Svelte组件",
+			"svelte",
+			"<script>\n  let count = 0; // 计数\n</script>\n<!-- 按钮 -->\n<button on:click={() => count++}>{count}</button>\n",
+			"<script>\n  let count = 0;\n</script>\n<button on:click={() => count++}>{count}</button>\n",
+		},
+		{
+			"Vue SCSS样式",
+			"vue",
+			"<style lang=\"scss\">\n// 变量\n$c: red; // 颜色\n.a { background: url(//cdn.example.com/a.png); color: $c; }\n</style>\n",
+			"<style lang=\"scss\">\n$c: red;\n.a { background: url(//cdn.example.com/a.png); color: $c; }\n</style>\n",
+		},
+		{
+			"Svelte Less样式",
+			"svelte",
+			"<p>hi</p>\n<style lang=\"less\">\n@c: red; // 颜色\np { color: @c; /* 块 */ }\n</style>\n",
+			"<p>hi</p>\n<style lang=\"less\">\n@c: red;\np { color: @c;  }\n</style>\n",
+		},
+		{
+			"Astro frontmatter",
+			"astro",
+			"---\n// 导入组件\nimport Card from './Card.astro';\nconst title = 'x'; // 标题\n---\n<h1>{title}</h1>\n<!-- 注释 -->\n",
+			"---\nimport Card from './Card.astro';\nconst title = 'x';\n---\n<h1>{title}</h1>\n",
+		},
+		{
+			"数据脚本和注释中的标签保持原样",
+			"html",
+			"<!-- <script>// 注释中的脚本</script> -->\n<script type=\"application/ld+json\">\n{\"url\": \"https://example.com\"}\n</script>\n<script type=\"text/x-template\"># 模板</script>\n",
+			"<script type=\"application/ld+json\">\n{\"url\": \"https://example.com\"}\n</script>\n<script type=\"text/x-template\"># 模板</script>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertStringEqual(t, tt.expected, removeComments(tt.input, tt.fileType), tt.name)
+		})
+	}
+}
+
+// TestJSXComments 测试 JSX/TSX 的注释删除：JSX 文本保持原样，{/* */} 整体删除
+func TestJSXComments(t *testing.T) {
+	input := `// 组件注释
+import React from 'react'; // 导入
+const id = <T,>(x: T): T => x; // 泛型
+export function App({ items }: Props) {
+  const n = items.length < 10 ? 1 : 2; // 比较
+  return (
+    <div className="app" data-url="http://x">
+      {/* JSX 注释 */}
+      <p>// not a comment</p>
+      <p>/* also text */ {n}</p>
+      {items.map(i => <Item key={i} />)}
+      {/*
+        多行注释
+      */}
+      <>text {` + "`tpl ${<b>// x</b>}`" + `}</>
+    </div>
+  );
+}
+`
+	expected := `import React from 'react';
+const id = <T,>(x: T): T => x;
+export function App({ items }: Props) {
+  const n = items.length < 10 ? 1 : 2;
+  return (
+    <div className="app" data-url="http://x">
+      <p>// not a comment</p>
+      <p>/* also text */ {n}</p>
+      {items.map(i => <Item key={i} />)}
+      <>text {` + "`tpl ${<b>// x</b>}`" + `}</>
+    </div>
+  );
+}
+`
+	assertStringEqual(t, expected, removeComments(input, "tsx"), "TSX")
+
+	jsx := "const el = <span>a</span>; // 行尾\nconst b = <p>{/* 注释 */}text</p>;\n"
+	assertStringEqual(t, "const el = <span>a</span>;\nconst b = <p>text</p>;\n", removeComments(jsx, "jsx"), "JSX")
+}
+
+// TestNestedBlockComments 测试可嵌套块注释的深度跟踪（行内注释删除后的空格处理与普通块注释一致）
+func TestNestedBlockComments(t *testing.T) {
+	tests := []struct {
+		fileType string
+		input    string
+		expected string
+	}{
+		{"rust", "let a = 1; /* outer /* inner */ still comment */ let b = 2;\n", "let a = 1;  let b = 2;\n"},
+		{"rust", "/* outer\n  /* inner */\n  still comment\n*/\nfn main() {}\n", "fn main() {}\n"},
+		{"swift", "let x = 1 /* a /* b */ c */\n", "let x = 1 \n"},
+		{"haskell", "main = print 1\n{- outer\n{- inner -}\nstill -}\nx = 2\n", "main = print 1\nx = 2\n"},
+		{"ocaml", "let x = 1 (* a (* b *) c *)\nlet y = 2\n", "let x = 1 \nlet y = 2\n"},
+		{"d", "int a; /+ outer /+ inner +/ still +/ int b;\n", "int a;  int b;\n"},
+		{"d", "/* 不嵌套 /* */\nint c;\n", "int c;\n"},
+		{"java", "/* a /* b */\nint c;\n", "int c;\n"},
+	}
+
+	for _, tt := range tests {
+		assertStringEqual(t, tt.expected, removeComments(tt.input, tt.fileType), tt.fileType+"嵌套注释")
+	}
+}
+
+// TestRawAndMultiLineStrings 测试原始字符串、多行字符串和 heredoc 中的注释标记被保护
+func TestRawAndMultiLineStrings(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		input    string
+		expected string
+	}{
+		{"C++原始字符串", "cpp", "auto s = R\"x(a // not\n/* still */ \")x\"; // real\nint y; // c\n", "auto s = R\"x(a // not\n/* still */ \")x\";\nint y;\n"},
+		{"头文件中的C++原始字符串", "h", "auto s = R\"(a \" // not)\"; // real\n", "auto s = R\"(a \" // not)\";\n"},
+		{"Rust原始字符串", "rust", "let s = r#\"a \" // no\"#; // yes\nlet t = br\"/* k */\";\n", "let s = r#\"a \" // no\"#;\nlet t = br\"/* k */\";\n"},
+		{"C#逐字和原始字符串", "cs", "var a = @\"c:\\ \"\" // no\"; // yes\nvar b = \"\"\"\n// raw\n\"\"\"; // yes\n", "var a = @\"c:\\ \"\" // no\";\nvar b = \"\"\"\n// raw\n\"\"\";\n"},
+		{"Java文本块", "java", "String s = \"\"\"\n  // kept\n  /* kept */\n  \"\"\"; // gone\n", "String s = \"\"\"\n  // kept\n  /* kept */\n  \"\"\";\n"},
+		{"Lua长字符串", "lua", "local s = [==[\n-- kept\n]==] -- gone\nlocal x = 1 -- gone\n", "local s = [==[\n-- kept\n]==]\nlocal x = 1\n"},
+		{"PHP nowdoc", "php", "$s = <<<'EOT'\n# kept // kept\nEOT;\n$x = 1; // gone\n", "$s = <<<'EOT'\n# kept // kept\nEOT;\n$x = 1;\n"},
+		{"Ruby heredoc", "rb", "s = <<~EOS\n  # kept\nEOS\nx = 1 # gone\n", "s = <<~EOS\n  # kept\nEOS\nx = 1\n"},
+		{"Shell heredoc", "sh", "cat <<'EOF' # gone\n# kept\nEOF\n# gone\n", "cat <<'EOF'\n# kept\nEOF\n"},
+		{"Dart三引号字符串", "dart", "var s = '''\n// keep\n'''; // c\nvar t = \"\"\"\n/* keep */\n\"\"\"; // c\n", "var s = '''\n// keep\n''';\nvar t = \"\"\"\n/* keep */\n\"\"\";\n"},
+		{"Dart原始字符串", "dart", "var p = r'C:\\'; // c\nvar q = r'''\n\\// keep\n'''; // c\n", "var p = r'C:\\';\nvar q = r'''\n\\// keep\n''';\n"},
+		{"Julia三引号字符串", "jl", "s = \"\"\"\n# keep\n\"\"\" # c\n", "s = \"\"\"\n# keep\n\"\"\"\n"},
+		{"Elixir文档字符串", "ex", "@doc \"\"\"\n# keep\n\"\"\"\ndef f, do: 1 # c\n", "@doc \"\"\"\n# keep\n\"\"\"\ndef f, do: 1\n"},
+		{"TOML多行字符串", "toml", "a = '''\n# keep \\\n''' # c\nb = \"\"\"\n# keep\n\"\"\"\n", "a = '''\n# keep \\\n'''\nb = \"\"\"\n# keep\n\"\"\"\n"},
+		{"F#三引号字符串", "fs", "let s = \"\"\"\n// keep \\\n\"\"\" // c\n", "let s = \"\"\"\n// keep \\\n\"\"\"\n"},
+		{"Nim三引号字符串", "nim", "let s = \"\"\"\n# keep\n\"\"\" # c\n", "let s = \"\"\"\n# keep\n\"\"\"\n"},
+		{"GDScript三引号字符串", "gd", "var s = \"\"\"\n# keep\n\"\"\" # c\n", "var s = \"\"\"\n# keep\n\"\"\"\n"},
+		{"Groovy斜杠字符串", "groovy", "def r = /a\\/\\/b/ // gone\ndef d = $/\n// kept\n/$\n", "def r = /a\\/\\/b/\ndef d = $/\n// kept\n/$\n"},
+	}
+
+	for _, tt := range tests {
+		assertStringEqual(t, tt.expected, removeComments(tt.input, tt.fileType), tt.name)
+	}
+}
+
+// TestSingleQuoteKinds 测试各语言中单引号的不同含义（字符字面量、生命周期、撇号、数字分隔符）
+func TestSingleQuoteKinds(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		input    string
+		expected string
+	}{
+		{"Rust生命周期", "rust", "fn f<'a>(s: &'a str) -> &'a str { s } // gone\n", "fn f<'a>(s: &'a str) -> &'a str { s }\n"},
+		{"Rust字符字面量", "rust", "let c = '\"'; // gone\nlet d = '\\''; // gone\n", "let c = '\"';\nlet d = '\\'';\n"},
+		{"C++数字分隔符", "cpp", "int n = 1'000'000; // gone\nint h = 0xFF'FF; // gone\n", "int n = 1'000'000;\nint h = 0xFF'FF;\n"},
+		{"Go字符字面量", "go", "if c == '\"' { // gone\n\tr := '/' // gone\n}\n", "if c == '\"' {\n\tr := '/'\n}\n"},
+		{"C字符字面量中的注释标记", "c", "char *p = \"//\"; char c = '/'; // gone\n", "char *p = \"//\"; char c = '/';\n"},
+		{"Haskell撇号", "haskell", "f x' = x' + 1 -- gone\ng = 'a' -- gone\n", "f x' = x' + 1\ng = 'a'\n"},
+		{"OCaml类型变量", "ml", "let id (x : 'a) : 'a = x (* gone *)\n", "let id (x : 'a) : 'a = x \n"},
+		{"Clojure引用", "clj", "(def xs '(1 2)) ; gone\n(str \"a;b\") ; gone\n", "(def xs '(1 2))\n(str \"a;b\")\n"},
+		{"JavaScript单引号字符串", "js", "var s = 'it\\'s // kept'; // gone\n", "var s = 'it\\'s // kept';\n"},
+	}
+
+	for _, tt := range tests {
+		assertStringEqual(t, tt.expected, removeComments(tt.input, tt.fileType), tt.name)
+	}
+}
+
+// TestJSRegexLiterals 测试 JS/TS 中正则表达式和除号的区分
+func TestJSRegexLiterals(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		input    string
+		expected string
+	}{
+		{"除号", "js", "const x = a / b // gone\n", "const x = a / b\n"},
+		{"括号后的除号", "js", "y = (a + b) / 2 // gone\n", "y = (a + b) / 2\n"},
+		{"后缀自增后的除号", "js", "z = i++ / 2 // gone\n", "z = i++ / 2\n"},
+		{"return后的正则", "js", "return /\\/\\//.test(s) // gone\n", "return /\\/\\//.test(s)\n"},
+		{"字符类中的斜杠", "js", "s.split(/[/]/g) // gone\n", "s.split(/[/]/g)\n"},
+		{"正则中的引号", "ts", "if (!/\"/.test(s) && /'/.test(t)) x = 1 // gone\n", "if (!/\"/.test(s) && /'/.test(t)) x = 1\n"},
+		{"三元表达式中的正则", "mjs", "const r = c ? /a\\/b/ : /c/i // gone\n", "const r = c ? /a\\/b/ : /c/i\n"},
+		{"JSX文件中的正则", "jsx", "const re = /<div>\\/\\//g // gone\nconst el = <p>hi</p>\n", "const re = /<div>\\/\\//g\nconst el = <p>hi</p>\n"},
+	}
+
+	for _, tt := range tests {
+		assertStringEqual(t, tt.expected, removeComments(tt.input, tt.fileType), tt.name)
+	}
+	assertStringEqual(t, "js", detectFileType("module.cjs"), ".cjs文件类型")
+}
+
+// TestShellWordComments 测试 shell 中 # 只在单词开头时开始注释
+func TestShellWordComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		input    string
+		expected string
+	}{
+		{"参数个数", "sh", "echo $# # gone\n", "echo $#\n"},
+		{"数组长度和单词中的井号", "bash", "n=${#arr[@]} # gone\nx=foo#bar # gone\n", "n=${#arr[@]}\nx=foo#bar\n"},
+		{"条件表达式", "bash", "if [[ $x == #* ]]; then # gone\n", "if [[ $x == #* ]]; then\n"},
+		{"命令替换", "bash", "n=$(echo a#b | wc -c) # gone\n", "n=$(echo a#b | wc -c)\n"},
+		{"case模式", "bash", "case \"$c\" in '#'*) echo h;; esac # gone\n", "case \"$c\" in '#'*) echo h;; esac\n"},
+		{"各种引号", "bash", "echo \"a # b\" 'c # d' $'e \\' # f' # gone\n", "echo \"a # b\" 'c # d' $'e \\' # f'\n"},
+		{"分号后的注释", "zsh", "color=#fff\necho a;# gone\n", "color=#fff\necho a;\n"},
+		{"heredoc内容", "bash", "cat <<EOF\n# kept $#\nEOF\necho done # gone\n", "cat <<EOF\n# kept $#\nEOF\necho done\n"},
+		{"fish单引号转义", "fish", "echo 'it\\'s # x' # gone\n", "echo 'it\\'s # x'\n"},
+		{"跨行双引号字符串", "bash", "msg=\"Usage:\n  # not a comment\n  foo\" # gone\n# gone\necho 1\n", "msg=\"Usage:\n  # not a comment\n  foo\"\necho 1\n"},
+		{"跨行单引号字符串", "sh", "x='a\n# kept\n' # gone\n", "x='a\n# kept\n'\n"},
+		{"跨行命令替换", "bash", "v=$(\n# kept\necho 1\n) # gone\n", "v=$(\n# kept\necho 1\n)\n"},
+	}
+
+	for _, tt := range tests {
+		assertStringEqual(t, tt.expected, removeComments(tt.input, tt.fileType), tt.name)
+	}
+}
+
+// TestPowerShellComments 测试 PowerShell 的块注释、here-string、反引号转义和帮助块保留
+func TestPowerShellComments(t *testing.T) {
+	input := "<#\n.SYNOPSIS\n  Does x.\n#>\nfunction F {\n  <# internal\n  note #>\n  $s = @\"\n# kept\n<# kept #>\n\"@\n  Write-Host \"a `\" # b\" # gone\n  Write-Host a#b 'c''#d' # gone\n}\n"
+	body := "function F {\n  $s = @\"\n# kept\n<# kept #>\n\"@\n  Write-Host \"a `\" # b\"\n  Write-Host a#b 'c''#d'\n}\n"
+
+	assertStringEqual(t, body, removeComments(input, "ps1"), "PowerShell注释")
+
+	keepPowerShellHelp = true
+	defer func() { keepPowerShellHelp = false }()
+	assertStringEqual(t, "<#\n.SYNOPSIS\n  Does x.\n#>\n"+body, removeComments(input, "ps1"), "保留PowerShell帮助块")
+	if isStreamable("ps1") {
+		t.Error("保留帮助块时 PowerShell 脚本不应流式处理")
+	}
+}
+
+// TestBatchComments 测试批处理脚本的 REM、:: 和 & REM 注释
+func TestBatchComments(t *testing.T) {
+	input := "@echo off\nREM header\n@rem quiet\n:: label comment\n:real_label\nset X=%~dp0 & rem trailing\necho REMARK #1 %%i\nif exist \"a & rem b\" (rem inside\n  echo x && REM y\n)\necho 50^& rem not\nfor %%i in (a b) do rem loop\n"
+	expected := "@echo off\n:real_label\nset X=%~dp0\necho REMARK #1 %%i\nif exist \"a & rem b\" (\n  echo x\n)\necho 50^& rem not\nfor %%i in (a b) do rem loop\n"
+
+	assertStringEqual(t, expected, removeComments(input, "bat"), "批处理注释")
+	assertStringEqual(t, "echo a\n", removeComments("echo a\nRem Done\n", "cmd"), "CMD注释")
+}
+
+// TestSQLDialects 测试 SQL 方言：MySQL 的 # 和 --、PostgreSQL 的嵌套注释和美元引用、优化器提示
+func TestSQLDialects(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		input    string
+		expected string
+	}{
+		{"MySQL井号和双破折号", "mysql", "SELECT 5--1; # gone\nSELECT 1; -- gone\nSELECT '#x', `a#b` FROM t; #gone\n", "SELECT 5--1;\nSELECT 1;\nSELECT '#x', `a#b` FROM t;\n"},
+		{"MySQL可执行注释和提示", "mysql", "/*!40101 SET NAMES utf8 */;\nSELECT /*+ MAX_EXECUTION_TIME(1) */ a FROM t;\n", "/*!40101 SET NAMES utf8 */;\nSELECT /*+ MAX_EXECUTION_TIME(1) */ a FROM t;\n"},
+		{"PostgreSQL嵌套注释", "psql", "/* outer /* inner */\nstill */\nSELECT 1;\n", "SELECT 1;\n"},
+		{"PostgreSQL美元引用", "psql", "CREATE FUNCTION f() RETURNS int AS $$\n  -- kept\n  SELECT 1; /* kept */\n$$ LANGUAGE sql; -- gone\nSELECT $tag$ -- kept $tag$, $1 -- gone\n", "CREATE FUNCTION f() RETURNS int AS $$\n  -- kept\n  SELECT 1; /* kept */\n$$ LANGUAGE sql;\nSELECT $tag$ -- kept $tag$, $1\n"},
+		{"Oracle提示", "plsql", "SELECT /*+ INDEX(t i) */ * FROM t; -- gone\n", "SELECT /*+ INDEX(t i) */ * FROM t;\n"},
+		{"提示之后的块注释", "mysql", "SELECT 1 /*+ hint */, 2 /* c */ FROM t;\n/*! 40101 x */ 1; /* e */\n", "SELECT 1 /*+ hint */, 2  FROM t;\n/*! 40101 x */ 1; \n"},
+		{"提示之后的块注释（标准SQL）", "sql", "SELECT /*+ FULL(t) */ a /* c */ FROM t;\n", "SELECT /*+ FULL(t) */ a  FROM t;\n"},
+	}
+
+	for _, tt := range tests {
+		assertStringEqual(t, tt.expected, removeComments(tt.input, tt.fileType), tt.name)
+	}
+
+	assertStringEqual(t, "mysql", detectFileTypeFromContent("a.sql", []byte("CREATE TABLE t (id int AUTO_INCREMENT) ENGINE=InnoDB;")), "检测MySQL")
+	assertStringEqual(t, "psql", detectFileTypeFromContent("a.sql", []byte("DO $$ BEGIN END $$;")), "检测PostgreSQL")
+	assertStringEqual(t, "sql", detectFileTypeFromContent("a.sql", []byte("SELECT 1;")), "标准SQL")
+
+	sqlDialect = SQLDialectMySQL
+	defer func() { sqlDialect = "" }()
+	assertStringEqual(t, "mysql", detectFileTypeFromContent("a.sql", []byte("DO $$ BEGIN END $$;")), "指定方言")
+	if err := validateSQLDialect("sqlite"); err == nil {
+		t.Error("无效的 SQL 方言应当报错")
+	}
+}
+
+// TestRubyPerlDocBlocks 测试 Ruby 的 =begin/=end、Perl 的 POD 和 __END__/__DATA__ 数据段
+func TestRubyPerlDocBlocks(t *testing.T) {
+	ruby := "x = 1 # gone\n=begin\ncomment\n\nmore\n=end\ny = 2\n__END__\n# data kept\nfoo # kept\n"
+	assertStringEqual(t, "x = 1\ny = 2\n__END__\n# data kept\nfoo # kept\n", removeComments(ruby, "rb"), "Ruby块注释和数据段")
+
+	perl := "use strict; # gone\n\n=head1 NAME\n\nFoo - bar\n\n=cut\n\nmy $x = 1; # gone\n__DATA__\n# kept\n"
+	assertStringEqual(t, "use strict;\n\n\nmy $x = 1;\n__DATA__\n# kept\n", removeComments(perl, "perl"), "删除POD")
+
+	keepPerlPOD = true
+	defer func() { keepPerlPOD = false }()
+	assertStringEqual(t, "use strict;\n\n=head1 NAME\n\nFoo - bar\n\n=cut\n\nmy $x = 1;\n__DATA__\n# kept\n", removeComments(perl, "pm"), "保留POD")
+}
+
+// TestLuaLongBracketsAndPrecedence 测试 Lua 的长注释和长字符串，以及同一位置选择最长的注释标记
+func TestLuaLongBracketsAndPrecedence(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		input    string
+		expected string
+	}{
+		{"Lua跨行长注释", "lua", "--[[\nblock\n]]\nlocal c = 3\n", "local c = 3\n"},
+		{"Lua带等号的长注释", "lua", "local c = 3 --[==[\n]] still\n]==] local d = 4\n", "local c = 3 \n local d = 4\n"},
+		{"Lua长字符串", "lua", "local s = [[ -- kept ]] -- gone\nlocal t = [=[\n-- kept\n]=]\n", "local s = [[ -- kept ]]\nlocal t = [=[\n-- kept\n]=]\n"},
+		{"Lua普通行注释", "lua", "--[ not long\nlocal e = 5 --[= not long\n", "local e = 5\n"},
+		{"Haskell块注释优先", "haskell", "{-- doc --}\nmain = print 1 -- gone\n", "main = print 1\n"},
+		{"MATLAB块注释", "m", "%{\nblock\n%}\nx = 1; %{ line\n", "x = 1;\n"},
+		{"MATLAB行内%{不开始块注释", "m", "x = 1; %{ note\ny = 2;\nz = 3;\n", "x = 1;\ny = 2;\nz = 3;\n"},
+		{"MATLAB块注释中的%}", "m", "%{\nblock %} still\n%}\ny = 2;\n", "y = 2;\n"},
+		// 行内块注释之后的行注释
+		{"Go块注释后的行注释", "go", "/* a */ x := 1 // d\n", " x := 1\n"},
+		{"C块注释后的行注释", "c", "foo(); /* a */ bar(); // b\nfoo(); /* a */ // b\n", "foo();  bar();\nfoo();\n"},
+		{"Lua长注释后的行注释", "lua", "x = 1 --[[ a ]] -- d\n", "x = 1\n"},
+		{"PowerShell块注释后的行注释", "ps1", "$a = 1 <# inline #> + 2 # c\n", "$a = 1  + 2\n"},
+		{"PostgreSQL嵌套注释后的行注释", "psql", "/* a /* b */ still */ SELECT 1; -- c\n", " SELECT 1;\n"},
+		{"Haskell块注释后的行注释", "haskell", "x = {- a -} 5 -- c\n", "x =  5\n"},
+		{"块注释后开始跨行块注释", "go", "/* a */ x := 1 /* b\nc */ y\n", " x := 1 \n y\n"},
+	}
+
+	for _, tt := range tests {
+		assertStringEqual(t, tt.expected, removeComments(tt.input, tt.fileType), tt.name)
+	}
+}
+
+// TestFortranForms 测试 Fortran 固定格式的列规则和续行、自由格式的 ! 注释以及 .f 文件检测
+func TestFortranForms(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		input    string
+		expected string
+	}{
+		{"固定格式注释行", "f", "C     comment\nc     comment\n* star\n! bang\n      PROGRAM MAIN\n      CALL count(x)\n      END\n", "      PROGRAM MAIN\n      CALL count(x)\n      END\n"},
+		{"固定格式续行", "for", "      X = 1 +\n     !    2 ! gone\n  100 CONTINUE\n", "      X = 1 +\n     !    2\n  100 CONTINUE\n"},
+		{"固定格式续行字符串", "fortran", "      PRINT *, 'a ! kept\n     &b' ! gone\n      S = 'it''s ! kept'\n", "      PRINT *, 'a ! kept\n     &b'\n      S = 'it''s ! kept'\n"},
+		{"固定格式tab续行", "f", "\tX = 'a !\n\t1b' ! gone\n", "\tX = 'a !\n\t1b'\n"},
+		{"自由格式注释", "f90", "program main ! gone\n  ! whole line\n  character :: c = \"x ! kept\"\n  call count(c)\nend program\n", "program main\n  character :: c = \"x ! kept\"\n  call count(c)\nend program\n"},
+		{"自由格式续行字符串", "f95", "s = 'a ! kept &\n    &b ! kept' ! gone\nt = 'c' &\n  // '!' ! gone\n", "s = 'a ! kept &\n    &b ! kept'\nt = 'c' &\n  // '!'\n"},
+	}
+
+	for _, tt := range tests {
+		assertStringEqual(t, tt.expected, removeComments(tt.input, tt.fileType), tt.name)
+	}
+
+	assertStringEqual(t, "fortran", detectFileTypeFromContent("a.f", []byte("C     demo\n      SUBROUTINE FOO(X)\n      END\n")), "固定格式子程序")
+	assertStringEqual(t, "f90", detectFileTypeFromContent("a.f", []byte("module m\ncontains\n  subroutine s()\n  end subroutine\nend module\n")), "自由格式模块")
+	assertStringEqual(t, "fortran", detectFileTypeFromContent("a.f", []byte("      REAL FUNCTION F(X)\n      F = X\n      END\n")), "固定格式函数")
+	assertStringEqual(t, "unknown", detectFileTypeFromContent("a.f", []byte("hello world\n")), "非Fortran")
+}
+
+// TestCobolComments 测试 COBOL 固定格式的指示区、续行和标识区，以及自由格式的 *> 注释
+func TestCobolComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		input    string
+		expected string
+	}{
+		{"固定格式注释行", "cob", "000100 IDENTIFICATION DIVISION.\n000200* comment\n000300/ page eject\n      *\n000400 PROGRAM-ID. HELLO.\n", "000100 IDENTIFICATION DIVISION.\n000400 PROGRAM-ID. HELLO.\n"},
+		{"固定格式行内注释", "cbl", "000500     MOVE 1 TO X. *> gone\n000600     *> whole line\n000700D    DISPLAY '*> kept'.\n000800     DISPLAY \"a*b\" '/'.\n", "000500     MOVE 1 TO X.\n000700D    DISPLAY '*> kept'.\n000800     DISPLAY \"a*b\" '/'.\n"},
+		{"保留标识区", "cob", "000900     MOVE 1 TO X. *> gone                                         HELLO001\n", "000900     MOVE 1 TO X.                                                 HELLO001\n"},
+		{"标识区中的标记", "cob", "001000     MOVE 1 TO X.                                                 *>ID0001\n", "001000     MOVE 1 TO X.                                                 *>ID0001\n"},
+		{"续行字符串", "cob", "001100     DISPLAY 'LONG *> TEXT\n001200* comment\n001300-    'MORE *> TEXT'. *> gone\n", "001100     DISPLAY 'LONG *> TEXT\n001300-    'MORE *> TEXT'.\n"},
+		{"自由格式", "cob", "      >>SOURCE FORMAT IS FREE\n*> header\nIDENTIFICATION DIVISION. *> gone\nPROGRAM-ID. HELLO.\n/ kept\nDISPLAY \"*> kept\" 'it''s *> kept'. *> gone\n", "      >>SOURCE FORMAT IS FREE\nIDENTIFICATION DIVISION.\nPROGRAM-ID. HELLO.\n/ kept\nDISPLAY \"*> kept\" 'it''s *> kept'.\n"},
+	}
+
+	for _, tt := range tests {
+		assertStringEqual(t, tt.expected, removeComments(tt.input, tt.fileType), tt.name)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import "strings"
+
+// isMarkupFileType 检查文件类型是否按区域处理（标记部分使用 HTML 规则，内嵌脚本和样式使用各自语言的规则）
+func isMarkupFileType(fileType string) bool {
+	switch fileType {
+	case "html", "htm", "vue", "svelte", "astro":
+		return true
+	}
+	return false
+}
+
+// removeMarkupComments 分区域删除 HTML 和单文件组件（Vue、Svelte、Astro）中的注释
+func removeMarkupComments(content, fileType string) string {
+	if fileType == "astro" {
+		// Astro 的 frontmatter（两行 --- 之间）是 TypeScript 代码
+		if start, end, ok := astroFrontmatter(content); ok {
+			return content[:start] + removeComments(content[start:end], "ts") + removeMarkupRegions(content[end:])
+		}
+	}
+	return removeMarkupRegions(content)
+}
+
+// astroFrontmatter 返回 Astro frontmatter 代码的起止位置（不含两端的 --- 行）
+func astroFrontmatter(content string) (int, int, bool) {
+	trimmed := strings.TrimLeft(content, " \t\r\n")
+	if !strings.HasPrefix(trimmed, "---") {
+		return 0, 0, false
+	}
+	offset := len(content) - len(trimmed)
+	lineEnd := strings.IndexByte(trimmed, '\n')
+	if lineEnd == -1 || strings.TrimSpace(trimmed[:lineEnd]) != "---" {
+		return 0, 0, false
+	}
+
+	start := offset + lineEnd + 1
+	for pos := start; pos < len(content); {
+		next := strings.IndexByte(content[pos:], '\n')
+		line := content[pos:]
+		if next != -1 {
+			line = content[pos : pos+next]
+		}
+		if strings.TrimSpace(line) == "---" {
+			return start, pos, true
+		}
+		if next == -1 {
+			break
+		}
+		pos += next + 1
+	}
+	return 0, 0, false
+}
+
+// removeMarkupRegions 扫描标记中的 <script> 和 <style> 元素，分别处理标记和元素内容
+// 扫描时跳过 HTML 注释，注释中的标签不会被当作元素
+func removeMarkupRegions(markup string) string {
+	var result strings.Builder
+	lower := strings.ToLower(markup)
+	pending := 0
+	for i := 0; i < len(markup); {
+		lt := strings.IndexByte(markup[i:], '<')
+		if lt == -1 {
+			break
+		}
+		i += lt
+
+		if strings.HasPrefix(markup[i:], "<!--") {
+			end := strings.Index(markup[i+4:], "-->")
+			if end == -1 {
+				break
+			}
+			i += 4 + end + 3
+			continue
+		}
+
+		name := ""
+		for _, tag := range []string{"script", "style"} {
+			if isTagStart(lower, i, tag) {
+				name = tag
+			}
+		}
+		if name == "" {
+			i++
+			continue
+		}
+
+		tagEnd := findTagEnd(markup, i)
+		if tagEnd == -1 {
+			break
+		}
+		openTag := markup[i:tagEnd]
+		if strings.HasSuffix(openTag, "/>") {
+			i = tagEnd
+			continue
+		}
+		closeStart := strings.Index(lower[tagEnd:], "</"+name)
+		if closeStart == -1 {
+			break
+		}
+		closeStart += tagEnd
+
+		result.WriteString(removeCommentsByFileType(markup[pending:tagEnd], "html"))
+		body := markup[tagEnd:closeStart]
+		if language := embeddedLanguage(name, openTag); language != "" {
+			body = removeComments(body, language)
+		}
+		result.WriteString(body)
+		pending = closeStart
+		i = closeStart + len(name) + 2
+	}
+	result.WriteString(removeCommentsByFileType(markup[pending:], "html"))
+	return result.String()
+}
+
+// isTagStart 检查 lower[pos:] 是否为指定名称的开始标签
+func isTagStart(lower string, pos int, name string) bool {
+	if !strings.HasPrefix(lower[pos:], "<"+name) {
+		return false
+	}
+	next := pos + len(name) + 1
+	if next >= len(lower) {
+		return false
+	}
+	switch lower[next] {
+	case '>', '/', ' ', '\t', '\n', '\r':
+		return true
+	}
+	return false
+}
+
+// findTagEnd 返回从 pos 开始的标签结束位置（'>' 之后），跳过引号内的 '>'
+func findTagEnd(markup string, pos int) int {
+	var quote byte
+	for i := pos; i < len(markup); i++ {
+		c := markup[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// tagAttribute 读取开始标签中的属性值（小写），属性不存在时返回空字符串和 false
+func tagAttribute(tag, name string) (string, bool) {
+	lower := strings.ToLower(tag)
+	for pos := 0; ; {
+		idx := strings.Index(lower[pos:], name)
+		if idx == -1 {
+			return "", false
+		}
+		idx += pos
+		pos = idx + len(name)
+		// 属性名前必须是空白，避免 xml:lang、data-type 等误匹配
+		if idx == 0 || !strings.ContainsRune(" \t\r\n", rune(lower[idx-1])) {
+			continue
+		}
+		// 属性名更长（如 language）时继续查找
+		if pos < len(lower) && !strings.ContainsRune(" \t\r\n=/>", rune(lower[pos])) {
+			continue
+		}
+		rest := strings.TrimLeft(lower[pos:], " \t\r\n")
+		if !strings.HasPrefix(rest, "=") {
+			return "", true
+		}
+		rest = strings.TrimLeft(rest[1:], " \t\r\n")
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			if end := strings.IndexByte(rest[1:], rest[0]); end != -1 {
+				return strings.TrimSpace(rest[1 : end+1]), true
+			}
+			return "", true
+		}
+		end := strings.IndexAny(rest, " \t\r\n/>")
+		if end == -1 {
+			end = len(rest)
+		}
+		return rest[:end], true
+	}
+}
+
+// embeddedLanguage 根据 <script>/<style> 的 lang、type 属性确定内容的语言，无法处理的内容返回空字符串
+func embeddedLanguage(name, openTag string) string {
+	lang, _ := tagAttribute(openTag, "lang")
+	if name == "style" {
+		switch lang {
+		case "", "css", "postcss":
+			return "css"
+		case "scss", "sass", "less":
+			return lang
+		}
+		return ""
+	}
+
+	switch lang {
+	case "ts", "typescript":
+		return "ts"
+	case "tsx", "jsx":
+		return lang
+	case "js", "javascript":
+		return "js"
+	case "":
+	default:
+		return ""
+	}
+
+	// type 属性决定 <script> 是否为可执行脚本，模板、JSON 等数据块保持原样
+	scriptType, ok := tagAttribute(openTag, "type")
+	if !ok {
+		return "js"
+	}
+	switch scriptType {
+	case "", "module", "text/javascript", "application/javascript":
+		return "js"
+	case "text/babel", "text/jsx":
+		return "jsx"
+	case "text/typescript", "application/typescript":
+		return "ts"
+	}
+	return ""
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// copyOwnership 复制文件属主，非特权用户无法修改时忽略
+func copyOwnership(dst string, info os.FileInfo) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		_ = os.Lchown(dst, int(st.Uid), int(st.Gid))
+	}
+}
+
+// copyXattrs 复制扩展属性，文件系统不支持或没有权限时忽略
+func copyXattrs(dst, src string) {
+	size, err := syscall.Listxattr(src, nil)
+	if err != nil || size <= 0 {
+		return
+	}
+	buf := make([]byte, size)
+	size, err = syscall.Listxattr(src, buf)
+	if err != nil {
+		return
+	}
+	
+	for _, name := range strings.Split(string(buf[:size]), "\x00") {
+		if name == "" {
+			continue
+		}
+		valueSize, err := syscall.Getxattr(src, name, nil)
+		if err != nil {
+			continue
+		}
+		value := make([]byte, valueSize)
+		valueSize, err = syscall.Getxattr(src, name, value)
+		if err != nil {
+			continue
+		}
+		_ = syscall.Setxattr(dst, name, value[:valueSize], 0)
+	}
+}
+
+// fileAccessTime 获取文件的访问时间
+func fileAccessTime(info os.FileInfo) time.Time {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return time.Unix(st.Atim.Unix())
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// TestXattrsCopiedFromSource 测试备份、原地写入和镜像输出都从原文件复制扩展属性
+func TestXattrsCopiedFromSource(t *testing.T) {
+	resetBackupGlobals()
+	defer func() {
+		outputDir = ""
+		copyAllFiles = false
+		resetBackupGlobals()
+	}()
+
+	base := t.TempDir()
+	source := filepath.Join(base, "src")
+	if err := os.MkdirAll(source, 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+	files := map[string]string{
+		"main.go":  "x := 1 // comment\n",
+		"logo.png": "\x89PNG",
+	}
+	for name, content := range files {
+		path := filepath.Join(source, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("创建文件失败: %v", err)
+		}
+		if err := syscall.Setxattr(path, "user.origin", []byte(name), 0); err != nil {
+			t.Skipf("文件系统不支持扩展属性: %v", err)
+		}
+	}
+
+	assertXattr := func(path, expected string) {
+		t.Helper()
+		value := make([]byte, 64)
+		n, err := syscall.Getxattr(path, "user.origin", value)
+		if err != nil {
+			t.Errorf("%s 缺少扩展属性: %v", path, err)
+			return
+		}
+		assertStringEqual(t, expected, string(value[:n]), path)
+	}
+
+	// 镜像输出：处理后的文件和原样复制的文件
+	var err error
+	outputDir, err = prepareOutputDir(filepath.Join(base, "out"), source)
+	if err != nil {
+		t.Fatalf("prepareOutputDir() error = %v", err)
+	}
+	copyAllFiles = true
+	if err := processDirectory(source); err != nil {
+		t.Fatalf("处理目录失败: %v", err)
+	}
+	assertXattr(filepath.Join(outputDir, "main.go"), "main.go")
+	assertXattr(filepath.Join(outputDir, "logo.png"), "logo.png")
+
+	// 原地处理：备份和替换后的源文件
+	outputDir, copyAllFiles = "", false
+	if err := processFile(filepath.Join(source, "main.go"), source); err != nil {
+		t.Fatalf("处理文件失败: %v", err)
+	}
+	assertXattr(filepath.Join(backupRootDir, "main.go"), "main.go")
+	assertXattr(filepath.Join(source, "main.go"), "main.go")
+}
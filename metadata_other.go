@@ -0,0 +1,19 @@
+//go:build !unix
+
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// copyOwnership Windows 等平台没有 Unix 属主概念，跳过
+func copyOwnership(dst string, info os.FileInfo) {}
+
+// copyXattrs 当前平台不支持扩展属性，跳过
+func copyXattrs(dst, src string) {}
+
+// fileAccessTime 获取文件的访问时间，不可用时使用修改时间
+func fileAccessTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
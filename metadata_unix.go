@@ -0,0 +1,24 @@
+//go:build unix && !linux
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// copyOwnership 复制文件属主，非特权用户无法修改时忽略
+func copyOwnership(dst string, info os.FileInfo) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		_ = os.Lchown(dst, int(st.Uid), int(st.Gid))
+	}
+}
+
+// copyXattrs 当前平台的标准库不提供扩展属性接口，跳过
+func copyXattrs(dst, src string) {}
+
+// fileAccessTime 获取文件的访问时间（部分平台字段名不同，统一使用修改时间）
+func fileAccessTime(info os.FileInfo) time.Time {
+	return info.ModTime()
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// prepareOutputDir 检查并创建镜像输出目录，输出目录不能与源目录相同
+func prepareOutputDir(outDir, sourceDir string) (string, error) {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return "", fmt.Errorf("创建输出目录失败: %v", err)
+	}
+	realOut, err := resolveRealPath(outDir)
+	if err != nil {
+		return "", fmt.Errorf("解析输出目录失败: %v", err)
+	}
+	realSource, err := resolveRealPath(sourceDir)
+	if err != nil {
+		return "", fmt.Errorf("解析源目录失败: %v", err)
+	}
+	if realOut == realSource || isWithinRoot(realSource, realOut) {
+		return "", fmt.Errorf("输出目录不能是源目录或其上级目录: %s", outDir)
+	}
+	return realOut, nil
+}
+
+// isOutputPath 检查路径是否位于镜像输出目录内（输出目录在源目录内时遍历需要跳过）
+func isOutputPath(path string) bool {
+	if outputDir == "" {
+		return false
+	}
+	realPath, err := resolveRealPath(path)
+	if err != nil {
+		return false
+	}
+	return isWithinRoot(realPath, outputDir)
+}
+
+// outputPathFor 计算源文件在镜像输出目录中的路径
+func outputPathFor(filePath, workingDir string) (string, error) {
+	relPath, err := filepath.Rel(workingDir, filePath)
+	if err != nil {
+		return "", fmt.Errorf("计算相对路径失败: %v", err)
+	}
+	return filepath.Join(outputDir, relPath), nil
+}
+
+// writeOutputFile 把处理结果写入镜像输出目录，源文件保持不变
+func writeOutputFile(filePath, workingDir string, data []byte, info os.FileInfo) error {
+	dest, err := outputPathFor(filePath, workingDir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+	return writeFileAtomic(dest, filePath, data, info)
+}
+
+// copyToOutput 把文件原样复制到镜像输出目录（仅在 --copy-all 时）
+func copyToOutput(filePath, workingDir string) {
+	if outputDir == "" || !copyAllFiles {
+		return
+	}
+	dest, err := outputPathFor(filePath, workingDir)
+	if err != nil {
+		printError("复制 %s 失败: %v", filePath, err)
+		return
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		printError("复制 %s 失败: %v", filePath, err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		printError("复制 %s 失败: %v", filePath, err)
+		return
+	}
+	if err := copyFileWithMetadata(dest, filePath, info); err != nil {
+		printError("复制 %s 失败: %v", filePath, err)
+	}
+}
+
+// copyTreeToOutput 把目录原样复制到镜像输出目录（仅在 --copy-all 时），用于不处理的隐藏目录；符号链接不复制
+func copyTreeToOutput(dir, workingDir string) error {
+	if outputDir == "" || !copyAllFiles {
+		return nil
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if isOutputPath(path) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			copyToOutput(path, workingDir)
+		}
+		return nil
+	})
+}
+
+// copyOutputAlias 同一物理文件的另一个路径（硬链接名或符号链接名）复制首个路径的输出，不重复删除注释
+// 首个路径没有输出时（如处理失败）按 --copy-all 原样复制
+func copyOutputAlias(path, first, workingDir string) {
+	if path == first {
+		return
+	}
+	src, err := outputPathFor(first, workingDir)
+	if err != nil {
+		printError("复制 %s 失败: %v", path, err)
+		return
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		copyToOutput(path, workingDir)
+		return
+	}
+	dest, err := outputPathFor(path, workingDir)
+	if err != nil {
+		printError("复制 %s 失败: %v", path, err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		printError("复制 %s 失败: %v", path, err)
+		return
+	}
+	if err := copyFileWithMetadata(dest, src, info); err != nil {
+		printError("复制 %s 失败: %v", path, err)
+		return
+	}
+	relPath, _ := filepath.Rel(workingDir, path)
+	fmt.Printf("%s "+ColorYellow+"与 %s 为同一文件，已复制"+ColorReset+"\n", relPath, filepath.Base(first))
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// notebookLanguage 根据 notebook 元数据中的内核语言返回注释规则使用的语言，无法处理时返回空字符串
+func notebookLanguage(metadata map[string]interface{}) string {
+	var language string
+	if kernelspec, ok := metadata["kernelspec"].(map[string]interface{}); ok {
+		language, _ = kernelspec["language"].(string)
+	}
+	if language == "" {
+		if languageInfo, ok := metadata["language_info"].(map[string]interface{}); ok {
+			language, _ = languageInfo["name"].(string)
+		}
+	}
+
+	switch strings.ToLower(language) {
+	case "", "python", "python3":
+		// nbformat 未声明语言时默认为 Python
+		return "python"
+	case "r":
+		return "r"
+	case "julia":
+		return "julia"
+	}
+	return ""
+}
+
+// notebookSource 读取单元格的 source 字段（nbformat 允许字符串或字符串数组）
+func notebookSource(cell map[string]interface{}) (string, bool) {
+	switch source := cell["source"].(type) {
+	case string:
+		return source, true
+	case []interface{}:
+		var sb strings.Builder
+		for _, line := range source {
+			text, ok := line.(string)
+			if !ok {
+				return "", false
+			}
+			sb.WriteString(text)
+		}
+		return sb.String(), true
+	}
+	return "", false
+}
+
+// setNotebookSource 写回单元格的 source 字段，保持原来的字符串或数组形式
+func setNotebookSource(cell map[string]interface{}, source string) {
+	if _, ok := cell["source"].(string); ok {
+		cell["source"] = source
+		return
+	}
+	lines := []interface{}{}
+	for _, line := range strings.SplitAfter(source, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	cell["source"] = lines
+}
+
+// removeNotebookComments 删除 Jupyter notebook 代码单元格中的注释，按选项清空输出或删除 markdown 单元格
+// 内容不是有效的 notebook 或没有任何修改时原样返回
+func removeNotebookComments(content string) string {
+	decoder := json.NewDecoder(strings.NewReader(content))
+	// 保留数字的原始写法
+	decoder.UseNumber()
+	var notebook map[string]interface{}
+	if err := decoder.Decode(&notebook); err != nil {
+		return content
+	}
+	cells, ok := notebook["cells"].([]interface{})
+	if !ok {
+		return content
+	}
+	metadata, _ := notebook["metadata"].(map[string]interface{})
+	language := notebookLanguage(metadata)
+
+	changed := false
+	kept := make([]interface{}, 0, len(cells))
+	for _, item := range cells {
+		cell, ok := item.(map[string]interface{})
+		if !ok {
+			kept = append(kept, item)
+			continue
+		}
+
+		switch cell["cell_type"] {
+		case "markdown":
+			if clearNotebookMarkdown {
+				changed = true
+				continue
+			}
+		case "code":
+			if language != "" {
+				if source, ok := notebookSource(cell); ok {
+					if stripped := removeComments(source, language); stripped != source {
+						setNotebookSource(cell, stripped)
+						changed = true
+					}
+				}
+			}
+			if clearNotebookOutputs {
+				if outputs, ok := cell["outputs"].([]interface{}); !ok || len(outputs) > 0 || cell["execution_count"] != nil {
+					changed = true
+				}
+				cell["outputs"] = []interface{}{}
+				cell["execution_count"] = nil
+			}
+		}
+		kept = append(kept, cell)
+	}
+	if !changed {
+		return content
+	}
+	notebook["cells"] = kept
+
+	// 与 nbformat 的写出格式一致：键排序、缩进 1 个空格、不转义非 ASCII 和 HTML 字符、末尾换行
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", " ")
+	if err := encoder.Encode(notebook); err != nil {
+		return content
+	}
+	return buf.String()
+}
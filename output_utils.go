@@ -56,4 +56,7 @@ func printSummary() {
 	if backupRootDir != "" {
 		fmt.Printf(" | 备份: "+ColorCyan+"%s"+ColorReset, backupRootDir)
 	}
+	if outputDir != "" {
+		fmt.Printf(" | 输出: "+ColorCyan+"%s"+ColorReset, outputDir)
+	}
 }
@@ -0,0 +1,139 @@
+package main
+
+import "strings"
+
+// powerShellHelpKeywords 基于注释的帮助中的关键字，Get-Help 等工具会读取包含这些关键字的注释块
+var powerShellHelpKeywords = []string{
+	".SYNOPSIS", ".DESCRIPTION", ".PARAMETER", ".EXAMPLE", ".INPUTS", ".OUTPUTS",
+	".NOTES", ".LINK", ".COMPONENT", ".ROLE", ".FUNCTIONALITY",
+	".FORWARDHELPTARGETNAME", ".FORWARDHELPCATEGORY", ".REMOTEHELPRUNSPACE", ".EXTERNALHELP",
+}
+
+// isInPowerShellString 检查位置是否在 PowerShell 字符串内
+// PowerShell 的转义字符是反引号（`"、`#），单引号字符串中 '' 表示一个引号
+func isInPowerShellString(line string, pos int, stringType StringType) bool {
+	if pos >= len(line) || stringType == StringTypeBacktick {
+		return false
+	}
+	var quote byte
+	for i := 0; i < pos; i++ {
+		c := line[i]
+		switch {
+		case c == '`' && quote != '\'':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		}
+	}
+	return quote != 0
+}
+
+// powerShellCommentStart 返回 PowerShell 一行中 # 行注释开始的位置，没有注释时返回 -1
+// # 只在记号开头时开始注释（Write-Host a#b 中的 # 是参数的一部分），同一行内的 <# #> 块注释会被跳过，
+// 未结束的 <# 由块注释规则处理
+func powerShellCommentStart(line string) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '`' && quote != '\'':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '$' && strings.HasPrefix(line[i:], "${"):
+			// ${name#x} 形式的变量名
+			if end := strings.IndexByte(line[i:], '}'); end != -1 {
+				i += end
+			}
+		case strings.HasPrefix(line[i:], "<#"):
+			end := strings.Index(line[i+2:], "#>")
+			if end == -1 {
+				return -1
+			}
+			i += 2 + end + 1
+		case c == '#':
+			if i == 0 || strings.IndexByte(" \t;(){}|&,=", line[i-1]) != -1 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// isPowerShellHelpBlock 检查 <# #> 块注释的内容是否为基于注释的帮助
+func isPowerShellHelpBlock(body string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.ToUpper(strings.TrimSpace(line))
+		for _, keyword := range powerShellHelpKeywords {
+			if strings.HasPrefix(trimmed, keyword) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// powerShellHelpBlocks 返回独占若干整行的帮助块区间（从 <# 所在行的行首到 #> 所在行的换行符之后）
+// here-string 中的内容不会被当作注释
+func powerShellHelpBlocks(content string) [][2]int {
+	var blocks [][2]int
+	hereEnd := ""
+	for pos := 0; pos < len(content); {
+		next := strings.IndexByte(content[pos:], '\n')
+		lineEnd := len(content)
+		if next != -1 {
+			lineEnd = pos + next + 1
+		}
+		trimmed := strings.TrimSpace(content[pos:lineEnd])
+
+		switch {
+		case hereEnd != "":
+			if strings.HasPrefix(trimmed, hereEnd) {
+				hereEnd = ""
+			}
+		case strings.HasSuffix(trimmed, `@"`) || strings.HasSuffix(trimmed, "@'"):
+			hereEnd = trimmed[len(trimmed)-1:] + "@"
+		case strings.HasPrefix(trimmed, "<#"):
+			end := strings.Index(content[pos:], "#>")
+			if end == -1 {
+				return blocks
+			}
+			end += pos
+			blockEnd := len(content)
+			if next := strings.IndexByte(content[end:], '\n'); next != -1 {
+				blockEnd = end + next + 1
+			}
+			if strings.TrimSpace(content[end+2:blockEnd]) == "" && isPowerShellHelpBlock(content[pos:end]) {
+				blocks = append(blocks, [2]int{pos, blockEnd})
+			}
+			pos = blockEnd
+			continue
+		}
+		pos = lineEnd
+	}
+	return blocks
+}
+
+// removePowerShellComments 删除 PowerShell 脚本中的注释，设置 --keep-ps-help 时保留基于注释的帮助块
+func removePowerShellComments(content string) string {
+	if !keepPowerShellHelp {
+		return removeCommentsByFileType(content, "ps1")
+	}
+	var result strings.Builder
+	pending := 0
+	for _, block := range powerShellHelpBlocks(content) {
+		result.WriteString(removeCommentsByFileType(content[pending:block[0]], "ps1"))
+		result.WriteString(content[block[0]:block[1]])
+		pending = block[1]
+	}
+	result.WriteString(removeCommentsByFileType(content[pending:], "ps1"))
+	return result.String()
+}
@@ -0,0 +1,143 @@
+package main
+
+import "strings"
+
+// isJSFileType 检查文件类型是否为 JavaScript/TypeScript（需要区分正则表达式和除号）
+func isJSFileType(fileType string) bool {
+	switch fileType {
+	case "javascript", "js", "mjs", "cjs", "jsx", "typescript", "ts", "mts", "cts", "tsx":
+		return true
+	}
+	return false
+}
+
+// regexAllowedAfter 根据前一个有效记号判断 / 是否开始正则表达式，否则是除号
+// lastSig 为 0 表示前面没有记号，标识符、字符串等值之后为 'a'；lastWord 为前一个标识符
+func regexAllowedAfter(lastSig byte, lastWord string) bool {
+	return lastSig == 0 || strings.IndexByte("(,=:[!&|?{};+-*%<>~^", lastSig) != -1 || regexPrefixKeywords[lastWord]
+}
+
+// jsRegexEnd 返回从 pos 的 / 开始的正则表达式字面量的结束位置（包括 gimsuyd 等标志），未在行内结束时返回 -1
+// 字符类 [...] 中的 / 不结束正则表达式
+func jsRegexEnd(line string, pos int) int {
+	inClass := false
+	for i := pos + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if inClass {
+				continue
+			}
+			if i == pos+1 {
+				// // 是注释，不是空的正则表达式
+				return -1
+			}
+			end := i + 1
+			for end < len(line) && isJSIdentChar(line[end]) {
+				end++
+			}
+			return end
+		}
+	}
+	return -1
+}
+
+// jsQuotedEnd 返回从 pos 的引号开始的字符串的结束位置，未在行内结束时返回行尾
+func jsQuotedEnd(line string, pos int) int {
+	quote := line[pos]
+	for i := pos + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+// jsRegexRanges 扫描一行 JS/TS 代码，返回正则表达式字面量的区间
+// 根据前一个有效记号区分正则表达式和除号：a / b 是除号，return /re/、(/re/)、x = /re/ 是正则表达式
+func jsRegexRanges(line string) [][2]int {
+	var ranges [][2]int
+	var lastSig byte
+	lastWord := ""
+
+	for i := 0; i < len(line); {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case strings.HasPrefix(line[i:], "//"):
+			return ranges
+		case strings.HasPrefix(line[i:], "/*"):
+			end := strings.Index(line[i+2:], "*/")
+			if end == -1 {
+				return ranges
+			}
+			i += end + 4
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			// 字符串之后相当于一个值
+			i = jsQuotedEnd(line, i)
+			lastSig, lastWord = 'a', ""
+			continue
+		case c == '/' && regexAllowedAfter(lastSig, lastWord):
+			end := jsRegexEnd(line, i)
+			if end == -1 {
+				i++
+				break
+			}
+			ranges = append(ranges, [2]int{i, end})
+			i = end
+			lastSig, lastWord = 'a', ""
+			continue
+		case (c == '+' || c == '-') && i+1 < len(line) && line[i+1] == c:
+			i += 2
+			if lastSig == 'a' || lastSig == ')' || lastSig == ']' {
+				// x++ / 2 中的 ++ 是后缀运算符，之后的 / 是除号
+				lastSig, lastWord = 'a', ""
+				continue
+			}
+		case isJSIdentChar(c):
+			start := i
+			for i < len(line) && isJSIdentChar(line[i]) {
+				i++
+			}
+			lastWord = line[start:i]
+			lastSig = 'a'
+			if regexPrefixKeywords[lastWord] {
+				lastSig = ' '
+			}
+			continue
+		default:
+			i++
+		}
+		lastSig, lastWord = line[i-1], ""
+	}
+	return ranges
+}
+
+// isInJSRegex 检查位置是否在 JS/TS 正则表达式字面量内
+func isInJSRegex(line string, pos int) bool {
+	for _, r := range jsRegexRanges(line) {
+		if pos >= r[0] && pos < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// isInRegexForLanguage 检查位置是否在正则表达式字面量内，JS/TS 根据前一个记号区分正则表达式和除号
+func isInRegexForLanguage(line string, pos int, fileType string) bool {
+	if isJSFileType(fileType) {
+		return isInJSRegex(line, pos)
+	}
+	return isInRegex(line, pos)
+}
@@ -0,0 +1,133 @@
+package main
+
+import "strings"
+
+// isShellFileType 检查文件类型是否为 shell 脚本（使用 shell 的单词规则识别注释）
+func isShellFileType(fileType string) bool {
+	switch fileType {
+	case "shell", "bash", "zsh", "sh", "ksh", "fish":
+		return true
+	}
+	return false
+}
+
+// isShellWordBoundary 检查字符之后是否开始一个新的单词（空白和 shell 元字符）
+func isShellWordBoundary(c byte) bool {
+	return strings.IndexByte(" \t;&|()<>", c) != -1
+}
+
+// shellParamEnd 返回 ${...} 参数展开的结束位置（} 之后），pos 指向其中的 {，未在行内结束时返回行尾
+// 参数展开中的 #（${#arr[@]}、${VAR#prefix}、${VAR##*/}）不是注释
+func shellParamEnd(line string, pos int) int {
+	depth := 0
+	for i := pos; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(line)
+}
+
+// shellState 是 shell 脚本中可以跨行延续的引号和命令替换状态
+type shellState struct {
+	quote      byte // 未结束的引号，$ 表示 $'...'
+	substDepth int  // $( 和 $(( 的括号深度
+}
+
+// shellCommentStart 返回 shell 脚本一行中注释开始的位置（没有注释时为 -1）和行尾（或注释处）的状态，
+// state 为上一行延续下来的状态
+// 按 POSIX shell 的规则，# 只在单词开头时开始注释：foo#bar、$#、${#arr[@]} 中的 # 不是注释；
+// 引号、$(...) 命令替换和 [[ ]] 条件表达式中的 # 也不是注释
+func shellCommentStart(line, fileType string, state shellState) (int, shellState) {
+	quote, substDepth := state.quote, state.substDepth
+	inTest := false // 在 [[ ]] 中
+	start := 0
+	if state == (shellState{}) && strings.HasPrefix(line, "#!") {
+		// 保护 shebang
+		start = 2
+	}
+	for i := start; i < len(line); i++ {
+		c := line[i]
+		switch quote {
+		case '\'':
+			// 单引号中没有转义（fish 允许 \' 和 \\）
+			if c == '\\' && fileType == "fish" {
+				i++
+			} else if c == '\'' {
+				quote = 0
+			}
+			continue
+		case '$':
+			// bash 的 $'...' 支持反斜杠转义
+			if c == '\\' {
+				i++
+			} else if c == '\'' {
+				quote = 0
+			}
+			continue
+		case '"', '`':
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			} else if c == '$' && i+1 < len(line) && line[i+1] == '{' {
+				i = shellParamEnd(line, i+1) - 1
+			}
+			continue
+		}
+
+		switch c {
+		case '\\':
+			i++
+		case '\'', '"', '`':
+			quote = c
+		case '$':
+			if i+1 >= len(line) {
+				break
+			}
+			switch line[i+1] {
+			case '#':
+				i++
+			case '{':
+				i = shellParamEnd(line, i+1) - 1
+			case '(':
+				substDepth++
+				i++
+			case '\'':
+				quote = '$'
+				i++
+			}
+		case '(':
+			if substDepth > 0 {
+				substDepth++
+			}
+		case ')':
+			if substDepth > 0 {
+				substDepth--
+			}
+		case '[':
+			if strings.HasPrefix(line[i:], "[[") && (i == 0 || isShellWordBoundary(line[i-1])) {
+				inTest = true
+				i++
+			}
+		case ']':
+			if inTest && strings.HasPrefix(line[i:], "]]") {
+				inTest = false
+				i++
+			}
+		case '#':
+			if substDepth == 0 && !inTest && (i == 0 || isShellWordBoundary(line[i-1])) {
+				return i, shellState{}
+			}
+		}
+	}
+	return -1, shellState{quote, substDepth}
+}
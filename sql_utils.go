@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// SQL 方言
+const (
+	SQLDialectAuto     = "auto"     // 根据文件内容检测（默认）
+	SQLDialectANSI     = "ansi"     // 标准 SQL：-- 和 /* */
+	SQLDialectMySQL    = "mysql"    // MySQL/MariaDB：另有 #，-- 之后必须是空白
+	SQLDialectPostgres = "postgres" // PostgreSQL：/* */ 可以嵌套，$$ 和 $tag$ 引用字符串
+	SQLDialectOracle   = "oracle"   // Oracle PL/SQL
+)
+
+// validateSQLDialect 检查 --sql-dialect 参数
+func validateSQLDialect(dialect string) error {
+	switch dialect {
+	case SQLDialectAuto, SQLDialectANSI, SQLDialectMySQL, SQLDialectPostgres, SQLDialectOracle:
+		return nil
+	}
+	return fmt.Errorf("无效的 SQL 方言: %s（可选 auto|ansi|mysql|postgres|oracle）", dialect)
+}
+
+// isSQLFileType 检查文件类型是否为 SQL（sql 为标准 SQL，mysql、psql、plsql 为各方言）
+func isSQLFileType(fileType string) bool {
+	switch fileType {
+	case "sql", "mysql", "psql", "plsql":
+		return true
+	}
+	return false
+}
+
+// detectSQLFileType 确定 .sql 文件的方言，--sql-dialect 指定时直接使用，否则根据内容中的方言特征检测
+func detectSQLFileType(content []byte) string {
+	switch sqlDialect {
+	case SQLDialectANSI:
+		return "sql"
+	case SQLDialectMySQL:
+		return "mysql"
+	case SQLDialectPostgres:
+		return "psql"
+	case SQLDialectOracle:
+		return "plsql"
+	}
+
+	upper := bytes.ToUpper(content)
+	mysql, postgres := 0, 0
+	for _, marker := range []string{"ENGINE=", "AUTO_INCREMENT", "/*!", "LOCK TABLES", "DELIMITER "} {
+		if bytes.Contains(upper, []byte(marker)) {
+			mysql++
+		}
+	}
+	for _, marker := range []string{"$$", "LANGUAGE PLPGSQL", "CREATE EXTENSION", "::", "\\CONNECT", "SERIAL"} {
+		if bytes.Contains(upper, []byte(marker)) {
+			postgres++
+		}
+	}
+	switch {
+	case mysql > postgres:
+		return "mysql"
+	case postgres > mysql:
+		return "psql"
+	}
+	return "sql"
+}
+
+// checkSQLProtection 检查 SQL 的保护规则
+func checkSQLProtection(ctx ProtectionContext) bool {
+	if isInStringForLanguage(ctx.Line, ctx.Pos, StringTypeAll, ctx.FileType) {
+		return true
+	}
+	rest := ctx.Line[ctx.Pos:]
+	switch ctx.CommentStart {
+	case "/*":
+		// 优化器提示 /*+ */ 和 MySQL 的可执行注释 /*! */ 会被数据库执行
+		return strings.HasPrefix(rest, "/*+") || strings.HasPrefix(rest, "/*!")
+	case "--":
+		// MySQL 的 -- 之后必须是空白或行尾（SELECT 5--1 是 5 - -1）
+		return ctx.FileType == "mysql" && len(rest) > 2 && rest[2] != ' ' && rest[2] != '\t' && rest[2] != '\r'
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// errUnsafeContent 流式处理时发现内容不适合处理（二进制、非UTF-8、超长行）
+var errUnsafeContent = errors.New("文件内容不安全")
+
+// streamBufferSize 流式读写的缓冲区大小
+const streamBufferSize = 64 * 1024
+
+// isStreamable 检查文件类型能否逐行流式处理：notebook 需要解析 JSON，HTML、单文件组件和 JSX 需要按区域切换规则
+func isStreamable(fileType string) bool {
+	if fileType == "ps1" {
+		// 保留帮助块时需要完整的块注释内容
+		return !keepPowerShellHelp
+	}
+	return fileType != "ipynb" && !isMarkupFileType(fileType) && !isJSXFileType(fileType)
+}
+
+// readStreamLine 读取一行（不含换行符），limit > 0 时超过限制立即返回错误，避免超长行占满内存
+func readStreamLine(reader *bufio.Reader, limit int) (string, bool, error) {
+	var buf []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		buf = append(buf, chunk...)
+		if limit > 0 && len(buf) > limit+1 {
+			return "", false, fmt.Errorf("%w: 行太长, 超过限制 %d 字符", errUnsafeContent, limit)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == io.EOF {
+			return string(buf), true, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		return string(buf[:len(buf)-1]), false, nil
+	}
+}
+
+// removeCommentsStream 以流的方式删除注释，内存占用只与单行长度相关
+// 返回内容是否发生变化；行的切分与合并方式和 removeComments 完全一致
+func removeCommentsStream(r io.Reader, w io.Writer, fileType string, lineLimit int) (bool, error) {
+	reader := bufio.NewReaderSize(r, streamBufferSize)
+	writer := bufio.NewWriterSize(w, streamBufferSize)
+
+	// 检查开头是否包含null字节
+	head, _ := reader.Peek(512)
+	if bytes.IndexByte(head, 0) != -1 {
+		return false, fmt.Errorf("%w: 二进制文件", errUnsafeContent)
+	}
+
+	stripper := newCommentStripper(fileType, getCommentRulesForLanguage(fileType))
+	changed := false
+	first := true
+	lineNo := 0
+
+	for {
+		line, last, err := readStreamLine(reader, lineLimit)
+		if err != nil {
+			return false, err
+		}
+		lineNo++
+		if !utf8.ValidString(line) {
+			return false, fmt.Errorf("%w: 第 %d 行不是有效的UTF-8", errUnsafeContent, lineNo)
+		}
+
+		processed, keep := stripper.processLine(line)
+		if !keep || processed != line {
+			changed = true
+		}
+		if keep {
+			if !first {
+				if err := writer.WriteByte('\n'); err != nil {
+					return false, err
+				}
+			}
+			if _, err := writer.WriteString(processed); err != nil {
+				return false, err
+			}
+			first = false
+		}
+
+		if last {
+			break
+		}
+	}
+
+	return changed, writer.Flush()
+}
+
+// processFileStream 以流式方式处理大文件：先写入同目录临时文件，有变化时再替换原文件
+func processFileStream(filePath, workingDir, fileType string) error {
+	src, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("读取文件信息失败: %v", err)
+	}
+
+	// 镜像输出模式写入输出目录，否则原地替换
+	dest := filePath
+	if outputDir != "" {
+		if dest, err = outputPathFor(filePath, workingDir); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("创建输出目录失败: %v", err)
+		}
+	}
+
+	tmp, err := createAtomicFile(dest, filePath, info)
+	if err != nil {
+		return err
+	}
+
+	lineLimit := maxLineLength
+	if forceMode {
+		lineLimit = 0
+	}
+
+	changed, err := removeCommentsStream(src, tmp, fileType, lineLimit)
+	if err != nil {
+		tmp.Abort()
+		if errors.Is(err, errUnsafeContent) {
+			skippedFiles = append(skippedFiles, filePath)
+			printWarning("文件 %s: %v，跳过处理", filePath, err)
+			copyToOutput(filePath, workingDir)
+			return nil
+		}
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+
+	relPath, _ := filepath.Rel(workingDir, filePath)
+	if !changed && outputDir == "" {
+		tmp.Abort()
+		fmt.Printf("%s "+ColorYellow+"|%s|"+ColorReset+" 无变化\n", relPath, strings.ToUpper(fileType))
+		return nil
+	}
+
+	// 创建备份（镜像输出模式不修改源文件，无需备份）
+	if outputDir == "" {
+		if err := createBackup(filePath, workingDir); err != nil {
+			tmp.Abort()
+			return fmt.Errorf("创建备份失败: %v", err)
+		}
+	}
+
+	if err := tmp.Commit(); err != nil {
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+	if err := recordJournal(JournalWritten, relPath); err != nil {
+		return err
+	}
+
+	processedFiles = append(processedFiles, filePath)
+	fmt.Printf("%s "+ColorGreen+"|%s|"+ColorReset+" "+ColorGreen+"✓"+ColorReset+"\n", relPath, strings.ToUpper(fileType))
+
+	return nil
+}
@@ -0,0 +1,303 @@
+package main
+
+import "strings"
+
+// 原始字符串和多行字符串的形式
+const (
+	StringFixed     = iota // 固定的开始、结束标记，如 Java/Kotlin 的 """..."""、PowerShell 的 @"..."@
+	StringCppRaw           // C++ R"delim(...)delim"
+	StringRustRaw          // Rust r"..."、r#"..."#、br#"..."#
+	StringCSharpRaw        // C# 11 原始字符串 """..."""（三个及以上的引号）
+	StringVerbatim         // C# 逐字字符串 @"..."，其中 "" 表示一个引号
+	StringLuaLong          // Lua 长字符串 [[...]]、[==[...]==]
+	StringHeredoc          // heredoc，从下一行开始，到单独一行的结束标记为止
+	StringSlashy           // Groovy 斜杠字符串 /.../
+	StringDollar           // PostgreSQL 美元引用字符串 $$...$$、$tag$...$tag$
+)
+
+// StringRule 定义语言的一种原始字符串或多行字符串，其中的内容不做注释处理
+type StringRule struct {
+	Kind    int
+	Start   string // StringFixed 的开始标记，StringHeredoc 的引导符（<< 或 <<<）
+	End     string // StringFixed 的结束标记
+	Escapes bool   // 反斜杠可以转义结束标记
+	Spaced  bool   // heredoc 引导符和标记之间允许空格（shell）
+	Word    bool   // StringFixed 的开始标记不能紧跟在标识符之后（Dart 的 r'...'）
+}
+
+// getStringRulesForLanguage 获取语言的原始字符串和多行字符串形式
+// Go/JS 的反引号和 Python 的三引号由 commentStripper 单独跟踪
+func getStringRulesForLanguage(fileType string) []StringRule {
+	switch fileType {
+	case "cpp", "cc", "cxx", "hpp", "h", "c++":
+		return []StringRule{{Kind: StringCppRaw}}
+	case "rust", "rs":
+		return []StringRule{{Kind: StringRustRaw}}
+	case "cs":
+		return []StringRule{{Kind: StringCSharpRaw}, {Kind: StringVerbatim}}
+	case "java", "kotlin", "kt", "scala":
+		return []StringRule{{Kind: StringFixed, Start: `"""`, End: `"""`, Escapes: fileType == "java"}}
+	case "swift":
+		return []StringRule{{Kind: StringFixed, Start: `"""`, End: `"""`, Escapes: true}}
+	case "groovy", "gradle":
+		return []StringRule{
+			{Kind: StringFixed, Start: `"""`, End: `"""`, Escapes: true},
+			{Kind: StringFixed, Start: "'''", End: "'''", Escapes: true},
+			{Kind: StringFixed, Start: "$/", End: "/$"},
+			{Kind: StringSlashy, Escapes: true},
+		}
+	case "dart":
+		// 原始字符串 r'...' 中的反斜杠不是转义字符
+		return []StringRule{
+			{Kind: StringFixed, Start: `r"""`, End: `"""`, Word: true},
+			{Kind: StringFixed, Start: "r'''", End: "'''", Word: true},
+			{Kind: StringFixed, Start: `"""`, End: `"""`, Escapes: true},
+			{Kind: StringFixed, Start: "'''", End: "'''", Escapes: true},
+			{Kind: StringFixed, Start: `r"`, End: `"`, Word: true},
+			{Kind: StringFixed, Start: "r'", End: "'", Word: true},
+		}
+	case "jl":
+		return []StringRule{{Kind: StringFixed, Start: `"""`, End: `"""`, Escapes: true}}
+	case "gd", "coffee", "ex", "exs":
+		return []StringRule{
+			{Kind: StringFixed, Start: `"""`, End: `"""`, Escapes: true},
+			{Kind: StringFixed, Start: "'''", End: "'''", Escapes: true},
+		}
+	case "toml":
+		// 多行基本字符串可以转义，多行字面量字符串不能
+		return []StringRule{
+			{Kind: StringFixed, Start: `"""`, End: `"""`, Escapes: true},
+			{Kind: StringFixed, Start: "'''", End: "'''"},
+		}
+	case "nim", "fs", "fsx", "fsharp", "erl":
+		// 三引号字符串中的反斜杠不是转义字符
+		return []StringRule{{Kind: StringFixed, Start: `"""`, End: `"""`}}
+	case "lua":
+		return []StringRule{{Kind: StringLuaLong}}
+	case "sql", "psql":
+		return []StringRule{{Kind: StringDollar}}
+	case "php":
+		return []StringRule{{Kind: StringHeredoc, Start: "<<<"}}
+	case "ruby", "rb":
+		return []StringRule{{Kind: StringHeredoc, Start: "<<"}}
+	case "ps1":
+		return []StringRule{
+			{Kind: StringFixed, Start: `@"`, End: `"@`},
+			{Kind: StringFixed, Start: "@'", End: "'@"},
+		}
+	case "shell", "bash", "zsh", "sh":
+		return []StringRule{{Kind: StringHeredoc, Start: "<<", Spaced: true}}
+	}
+	return nil
+}
+
+// isIdentByte 检查字符是否可以出现在标识符中
+func isIdentByte(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// precededByIdent 检查 line[pos] 之前是否紧跟标识符字符
+func precededByIdent(line string, pos int) bool {
+	return pos > 0 && isIdentByte(line[pos-1])
+}
+
+// matchOpen 检查 line[i:] 是否以该字符串形式开始，返回内容的起点和结束标记
+// heredoc 的结束标记是单独一行的标识符，内容从下一行开始
+func (r StringRule) matchOpen(line string, i int) (int, string, bool) {
+	rest := line[i:]
+	switch r.Kind {
+	case StringFixed:
+		if strings.HasPrefix(rest, r.Start) && !(r.Word && precededByIdent(line, i)) {
+			return i + len(r.Start), r.End, true
+		}
+
+	case StringCppRaw:
+		if !strings.HasPrefix(rest, `R"`) {
+			return 0, "", false
+		}
+		// 允许 u8R、uR、UR、LR 编码前缀
+		if precededByIdent(line, i) {
+			word := i
+			for word > 0 && isIdentByte(line[word-1]) {
+				word--
+			}
+			switch line[word:i] {
+			case "u8", "u", "U", "L":
+			default:
+				return 0, "", false
+			}
+		}
+		open := strings.IndexByte(rest[2:], '(')
+		if open == -1 || open > 16 || strings.ContainsAny(rest[2:2+open], " \\)\t\"") {
+			return 0, "", false
+		}
+		delimiter := rest[2 : 2+open]
+		return i + 2 + open + 1, ")" + delimiter + `"`, true
+
+	case StringRustRaw:
+		j := i
+		if j < len(line) && line[j] == 'b' {
+			j++
+		}
+		if j >= len(line) || line[j] != 'r' || precededByIdent(line, i) {
+			return 0, "", false
+		}
+		j++
+		hashes := 0
+		for j < len(line) && line[j] == '#' {
+			hashes++
+			j++
+		}
+		if j >= len(line) || line[j] != '"' {
+			return 0, "", false
+		}
+		return j + 1, `"` + strings.Repeat("#", hashes), true
+
+	case StringCSharpRaw:
+		if i > 0 && line[i-1] == '"' {
+			return 0, "", false
+		}
+		quotes := 0
+		for i+quotes < len(line) && line[i+quotes] == '"' {
+			quotes++
+		}
+		if quotes < 3 {
+			return 0, "", false
+		}
+		return i + quotes, strings.Repeat(`"`, quotes), true
+
+	case StringVerbatim:
+		for _, prefix := range []string{`@"`, `$@"`, `@$"`} {
+			if strings.HasPrefix(rest, prefix) {
+				return i + len(prefix), `"`, true
+			}
+		}
+
+	case StringLuaLong:
+		if level, ok := longBracketLevel(line, i); ok {
+			return i + level + 2, "]" + strings.Repeat("=", level) + "]", true
+		}
+
+	case StringHeredoc:
+		if !strings.HasPrefix(rest, r.Start) || (i > 0 && line[i-1] == '<') {
+			return 0, "", false
+		}
+		j := i + len(r.Start)
+		// bash 的 <<< 是单行的 here-string
+		if r.Start == "<<" && j < len(line) && line[j] == '<' {
+			return 0, "", false
+		}
+		if j < len(line) && (line[j] == '-' || line[j] == '~') && r.Start == "<<" {
+			j++
+		}
+		if r.Spaced {
+			for j < len(line) && (line[j] == ' ' || line[j] == '\t') {
+				j++
+			}
+		}
+		var quote byte
+		if j < len(line) && (line[j] == '\'' || line[j] == '"' || (r.Spaced && line[j] == '\\')) {
+			quote = line[j]
+			j++
+		}
+		start := j
+		for j < len(line) && isIdentByte(line[j]) {
+			j++
+		}
+		if j == start || (line[start] >= '0' && line[start] <= '9') {
+			return 0, "", false
+		}
+		marker := line[start:j]
+		if quote == '\'' || quote == '"' {
+			if j >= len(line) || line[j] != quote {
+				return 0, "", false
+			}
+			j++
+		}
+		return j, marker, true
+
+	case StringDollar:
+		// $1 是位置参数，a$b 是标识符的一部分
+		if !strings.HasPrefix(rest, "$") || precededByIdent(line, i) {
+			return 0, "", false
+		}
+		j := i + 1
+		for j < len(line) && isIdentByte(line[j]) {
+			j++
+		}
+		if j >= len(line) || line[j] != '$' || (j > i+1 && line[i+1] >= '0' && line[i+1] <= '9') {
+			return 0, "", false
+		}
+		return j + 1, line[i : j+1], true
+
+	case StringSlashy:
+		// 只有在可以出现值的位置，/ 才是斜杠字符串而不是除号
+		if !strings.HasPrefix(rest, "/") || len(rest) < 2 || strings.ContainsRune("/* =", rune(rest[1])) {
+			return 0, "", false
+		}
+		before := strings.TrimRight(line[:i], " \t")
+		if before != "" && !strings.ContainsRune("=(,:[!&|?{", rune(before[len(before)-1])) && !strings.HasSuffix(before, "return") {
+			return 0, "", false
+		}
+		return i + 1, "/", true
+	}
+	return 0, "", false
+}
+
+// longBracketLevel 检查 line[pos:] 是否以 Lua 长括号 [[、[=[、[==[ 等开始，返回等号的个数
+func longBracketLevel(line string, pos int) (int, bool) {
+	if pos >= len(line) || line[pos] != '[' {
+		return 0, false
+	}
+	level := 0
+	for pos+1+level < len(line) && line[pos+1+level] == '=' {
+		level++
+	}
+	if pos+1+level < len(line) && line[pos+1+level] == '[' {
+		return level, true
+	}
+	return 0, false
+}
+
+// findEnd 从 from 开始查找结束标记，返回结束标记之后的位置，未找到时返回 -1
+func (r StringRule) findEnd(line string, from int, end string) int {
+	for i := from; i < len(line); i++ {
+		if r.Escapes && line[i] == '\\' {
+			i++
+			continue
+		}
+		if r.Kind == StringVerbatim && line[i] == '"' {
+			// "" 是转义的引号
+			if i+1 < len(line) && line[i+1] == '"' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+		if strings.HasPrefix(line[i:], end) {
+			return i + len(end)
+		}
+	}
+	return -1
+}
+
+// isHeredocTerminator 检查一行是否为 heredoc 的结束标记（允许缩进，PHP 允许后跟 ; , )）
+func isHeredocTerminator(line, marker string) bool {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, marker) {
+		return false
+	}
+	rest := strings.TrimSpace(trimmed[len(marker):])
+	return rest == "" || strings.ContainsRune(";,)", rune(rest[0]))
+}
+
+// maskRanges 把受保护区间中的字符替换为占位符，使注释检测忽略其中的注释标记和引号
+func maskRanges(line string, ranges [][2]int) string {
+	masked := []byte(line)
+	for _, r := range ranges {
+		for i := r[0]; i < r[1]; i++ {
+			masked[i] = '_'
+		}
+	}
+	return string(masked)
+}
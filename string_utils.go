@@ -1,6 +1,9 @@
 package main
 
-import "strings"
+import (
+	"strings"
+	"unicode/utf8"
+)
 
 // StringType 字符串类型枚举
 type StringType int
@@ -53,6 +56,125 @@ func isInStringWithType(line string, pos int, stringType StringType) bool {
 	}
 }
 
+// 单引号在各语言中的含义
+const (
+	QuoteString         = iota // 字符串定界符（JavaScript、Python、PHP、Shell、SQL 等）
+	QuoteChar                  // 字符字面量（C/C++、Java、Go、C# 等），C++ 数字中的 ' 是数字分隔符
+	QuoteCharOrLifetime        // 字符字面量或生命周期（Rust 的 &'a str）
+	QuotePrime                 // 标识符后的撇号（Haskell、OCaml、F# 的 x'），其他位置是字符字面量
+	QuoteSymbol                // 引用符号，不构成字符串（Lisp 的 '(a b)、Verilog 的 8'hFF）
+)
+
+// singleQuoteKind 获取语言中单引号的含义
+func singleQuoteKind(fileType string) int {
+	switch fileType {
+	case "c", "cpp", "cc", "cxx", "h", "hpp", "c++", "java", "kotlin", "kt", "scala", "go", "cs", "d", "zig", "odin", "nim":
+		return QuoteChar
+	case "rust", "rs":
+		return QuoteCharOrLifetime
+	case "haskell", "hs", "elm", "ml", "ocaml", "fs", "fsx", "fsharp":
+		return QuotePrime
+	case "lisp", "lsp", "scm", "clj", "cljs", "el", "verilog", "v", "vh", "sv":
+		return QuoteSymbol
+	}
+	return QuoteString
+}
+
+// isHexDigit 检查字符是否为十六进制数字
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// isDigitSeparator 检查 line[pos] 的单引号是否为数字分隔符（C++14 的 1'000'000、0xFF'FF）
+func isDigitSeparator(line string, pos int) bool {
+	if pos == 0 || pos+1 >= len(line) || !isHexDigit(line[pos-1]) || !isHexDigit(line[pos+1]) {
+		return false
+	}
+	// 向前找到数字的开头，必须以数字开始
+	start := pos - 1
+	for start > 0 && (isIdentByte(line[start-1]) || line[start-1] == '\'') {
+		start--
+	}
+	return line[start] >= '0' && line[start] <= '9'
+}
+
+// charLiteralEnd 匹配从 pos 开始的字符字面量（'x'、'\n'、'\u{1F600}'、'中'），返回结束位置，不是字符字面量时返回 -1
+func charLiteralEnd(line string, pos int) int {
+	if pos+2 >= len(line) {
+		return -1
+	}
+	if line[pos+1] == '\\' {
+		// 转义序列最长如 '\u{10FFFF}'
+		for i := pos + 3; i < len(line) && i <= pos+12; i++ {
+			if line[i] == '\'' {
+				return i + 1
+			}
+		}
+		return -1
+	}
+	_, size := utf8.DecodeRuneInString(line[pos+1:])
+	if line[pos+1] != '\'' && pos+1+size < len(line) && line[pos+1+size] == '\'' {
+		return pos + 2 + size
+	}
+	return -1
+}
+
+// isInStringForLanguage 按语言的单引号规则检查位置是否在字符串内
+// 字符字面量（包括 '"'）、生命周期、撇号和数字分隔符不会被当作字符串的开始
+func isInStringForLanguage(line string, pos int, stringType StringType, fileType string) bool {
+	if fileType == "ps1" {
+		return isInPowerShellString(line, pos, stringType)
+	}
+	quoteKind := singleQuoteKind(fileType)
+	if quoteKind == QuoteString {
+		return isInStringWithType(line, pos, stringType)
+	}
+	if pos >= len(line) {
+		return false
+	}
+	
+	var inDoubleQuote, inBacktick bool
+	for i := 0; i < pos; i++ {
+		switch line[i] {
+		case '\'':
+			if inDoubleQuote || inBacktick || quoteKind == QuoteSymbol {
+				continue
+			}
+			if quoteKind == QuotePrime && i > 0 && isIdentByte(line[i-1]) {
+				continue
+			}
+			if quoteKind == QuoteChar && isDigitSeparator(line, i) {
+				continue
+			}
+			if end := charLiteralEnd(line, i); end != -1 {
+				if pos < end {
+					// 位置在字符字面量内
+					return stringType != StringTypeBacktick
+				}
+				i = end - 1
+			}
+			// 其他情况（生命周期、未闭合的 '）不影响字符串状态
+		case '"':
+			if !inBacktick && !isEscaped(line, i) {
+				inDoubleQuote = !inDoubleQuote
+			}
+		case '`':
+			if !inDoubleQuote {
+				inBacktick = !inBacktick
+			}
+		}
+	}
+	
+	switch stringType {
+	case StringTypeQuote:
+		return inDoubleQuote
+	case StringTypeBacktick:
+		return inBacktick
+	default:
+		return inDoubleQuote || inBacktick
+	}
+}
+
 // isEscaped 检查字符是否被转义
 func isEscaped(line string, pos int) bool {
 	if pos == 0 {
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask 需要关注的 inotify 事件：写入完成、移动进入、新建（用于发现新目录）
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_CREATE
+
+// newInotifyWatcher 使用 inotify 递归监听目录
+func newInotifyWatcher(root string) (*fileWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	// 非阻塞描述符交给运行时的网络轮询器，Close 时可以唤醒阻塞的 Read
+	file := os.NewFile(uintptr(fd), "inotify")
+
+	var mu sync.Mutex
+	dirs := make(map[int32]string)
+	addDir := func(dir string) {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if path != root && isWatchIgnored(root, path) {
+				return fs.SkipDir
+			}
+			wd, err := syscall.InotifyAddWatch(fd, path, inotifyMask)
+			if err == nil {
+				mu.Lock()
+				dirs[int32(wd)] = path
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	addDir(root)
+	if len(dirs) == 0 {
+		file.Close()
+		return nil, syscall.ENOENT
+	}
+
+	done := make(chan struct{})
+	watcher := &fileWatcher{
+		Events: make(chan string, 256),
+		Errors: make(chan error, 1),
+		stop: func() {
+			close(done)
+			file.Close()
+		},
+	}
+
+	go func() {
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				// 监听器已关闭
+				return
+			}
+			for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+				event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				nameBytes := buf[offset+syscall.SizeofInotifyEvent : offset+syscall.SizeofInotifyEvent+int(event.Len)]
+				offset += syscall.SizeofInotifyEvent + int(event.Len)
+
+				mu.Lock()
+				dir, ok := dirs[event.Wd]
+				mu.Unlock()
+				if !ok || event.Len == 0 {
+					continue
+				}
+				name := string(nameBytes[:clen(nameBytes)])
+				path := filepath.Join(dir, name)
+
+				if event.Mask&syscall.IN_ISDIR != 0 {
+					// 新建或移入的目录需要加入监听
+					if event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0 && !isWatchIgnored(root, path) {
+						addDir(path)
+					}
+					continue
+				}
+				// 仅创建而未写入完成的文件等待 IN_CLOSE_WRITE
+				if event.Mask&(syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO) == 0 {
+					continue
+				}
+				select {
+				case watcher.Events <- path:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+
+	return watcher, nil
+}
+
+// clen 返回以 NUL 结尾的字节串长度
+func clen(b []byte) int {
+	for i, c := range b {
+		if c == 0 {
+			return i
+		}
+	}
+	return len(b)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// newInotifyWatcher 当前平台不支持 inotify，由调用方退回轮询模式
+func newInotifyWatcher(root string) (*fileWatcher, error) {
+	return nil, errors.New("当前平台不支持 inotify")
+}
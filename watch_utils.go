@@ -0,0 +1,211 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 监听模式参数
+const (
+	watchDebounce     = 300 * time.Millisecond // 同一文件连续变化合并处理的等待时间
+	watchTickInterval = 100 * time.Millisecond // 检查待处理文件的间隔
+	watchPollInterval = time.Second            // 轮询模式的扫描间隔
+)
+
+// fileWatcher 文件变化监听器，Events 输出发生变化的文件路径
+type fileWatcher struct {
+	Events chan string
+	Errors chan error
+	stop   func()
+}
+
+// Close 停止监听
+func (w *fileWatcher) Close() {
+	w.stop()
+}
+
+// fileStamp 文件的大小和修改时间，用于识别本工具自身的写入
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// statStamp 获取文件的大小和修改时间
+func statStamp(path string) (fileStamp, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, false
+	}
+	return fileStamp{size: info.Size(), modTime: info.ModTime()}, true
+}
+
+// isWatchIgnored 检查相对路径是否应被监听忽略（隐藏文件、备份目录以及原子写入的临时文件）
+func isWatchIgnored(root, path string) bool {
+	relPath, err := filepath.Rel(root, path)
+	if err != nil || !isWithinRoot(path, root) {
+		return true
+	}
+	if relPath == "." {
+		return false
+	}
+	if isHiddenOrBackupPath(relPath) || isOutputPath(path) {
+		return true
+	}
+	// 备份目录本身
+	return strings.HasPrefix(filepath.ToSlash(relPath)+"/", "bak/")
+}
+
+// newFileWatcher 创建监听器：Linux 上优先使用 inotify，不可用时退回轮询
+func newFileWatcher(root string, poll bool) *fileWatcher {
+	if !poll {
+		watcher, err := newInotifyWatcher(root)
+		if err == nil {
+			return watcher
+		}
+		printWarning("无法使用系统文件通知（%v），改用轮询模式", err)
+	}
+	return newPollWatcher(root, watchPollInterval)
+}
+
+// newPollWatcher 创建轮询监听器，定期扫描目录比较文件大小和修改时间
+func newPollWatcher(root string, interval time.Duration) *fileWatcher {
+	done := make(chan struct{})
+	watcher := &fileWatcher{
+		Events: make(chan string, 256),
+		Errors: make(chan error, 1),
+		stop:   func() { close(done) },
+	}
+
+	scan := func() map[string]fileStamp {
+		stamps := make(map[string]fileStamp)
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if path != root && isWatchIgnored(root, path) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if d.Type().IsRegular() {
+				if info, err := d.Info(); err == nil {
+					stamps[path] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+				}
+			}
+			return nil
+		})
+		return stamps
+	}
+
+	go func() {
+		previous := scan()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				current := scan()
+				for path, stamp := range current {
+					if old, ok := previous[path]; !ok || old != stamp {
+						select {
+						case watcher.Events <- path:
+						case <-done:
+							return
+						}
+					}
+				}
+				previous = current
+			}
+		}
+	}()
+
+	return watcher
+}
+
+// watchDirectory 在首次处理后持续监听目录，文件写入后重新删除注释，直到收到中断信号
+func watchDirectory(rootDir string, poll bool) error {
+	root, err := resolveRealPath(rootDir)
+	if err != nil {
+		return err
+	}
+
+	watcher := newFileWatcher(root, poll)
+	defer watcher.Close()
+	printInfo("正在监听 %s 的文件变化（Ctrl-C 停止）", root)
+
+	// 记录每个文件最后一次处理后的状态，过滤本工具自身写入触发的事件
+	lastSeen := make(map[string]fileStamp)
+	pending := make(map[string]time.Time)
+	ticker := time.NewTicker(watchTickInterval)
+	defer ticker.Stop()
+
+	for {
+		if interrupted.Load() {
+			return nil
+		}
+
+		select {
+		case path := <-watcher.Events:
+			if isWatchIgnored(root, path) {
+				continue
+			}
+			pending[path] = time.Now()
+		case err := <-watcher.Errors:
+			return err
+		case <-ticker.C:
+			var ready []string
+			for path, last := range pending {
+				if time.Since(last) >= watchDebounce {
+					ready = append(ready, path)
+				}
+			}
+			if len(ready) == 0 {
+				continue
+			}
+
+			tracker, err := newLinkTracker(root)
+			if err != nil {
+				return err
+			}
+			for _, path := range ready {
+				delete(pending, path)
+				stamp, ok := statStamp(path)
+				if !ok || lastSeen[path] == stamp {
+					continue
+				}
+				processWatchedFile(tracker, path)
+				if stamp, ok := statStamp(path); ok {
+					lastSeen[path] = stamp
+				}
+			}
+		}
+	}
+}
+
+// processWatchedFile 按目录遍历相同的规则处理一个发生变化的文件
+func processWatchedFile(tracker *linkTracker, path string) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if err := handleSymlink(tracker, path); err != nil {
+			printError("%v", err)
+		}
+		return
+	}
+	if !info.Mode().IsRegular() {
+		return
+	}
+	if !isSupportedFile(path, forceMode) {
+		copyToOutput(path, tracker.root)
+		return
+	}
+	processTrackedFile(tracker, path)
+}